@@ -0,0 +1,129 @@
+package gdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/vcs"
+)
+
+// A repoCache is an on disk cache of resolved repos, keyed by the repo root import path.
+type repoCache struct {
+	path    string
+	ttl     time.Duration
+	offline bool
+	entries map[string]*repoCacheEntry
+	dirty   bool
+}
+
+type repoCacheEntry struct {
+	VCS      string    // vcs command, i.e. git
+	Repo     string    // repo url
+	Resolved time.Time // time the repo was resolved
+}
+
+// DefaultCachePath returns the default location of the repo cache,
+// or an empty string if there is no user cache directory.
+func DefaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gdl", "repos.json")
+}
+
+// openRepoCache reads the cache from path, if it exists.
+// An empty path creates a cache that is only kept in memory.
+// Entries older than ttl are ignored, unless offline is true.
+// An invalid cache file is treated as empty, with a warning, and replaced when the cache is saved.
+func openRepoCache(path string, ttl time.Duration, offline bool) (*repoCache, []string, error) {
+	c := &repoCache{
+		path:    path,
+		ttl:     ttl,
+		offline: offline,
+		entries: make(map[string]*repoCacheEntry),
+	}
+	if path == "" {
+		return c, nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading repo cache")
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]*repoCacheEntry)
+		return c, []string{fmt.Sprintf("ignoring invalid repo cache %s: %v", path, err)}, nil
+	}
+	return c, nil, nil
+}
+
+// lookup returns the cached repo of the longest repo root that is a prefix of the import path.
+func (c *repoCache) lookup(importPath string) (*vcs.RepoRoot, bool) {
+	for root := importPath; ; {
+		if e, ok := c.entries[root]; ok && (c.offline || time.Since(e.Resolved) < c.ttl) {
+			cmd := vcs.ByCmd(e.VCS)
+			if cmd == nil {
+				cmd = &vcs.Cmd{Name: e.VCS, Cmd: e.VCS}
+			}
+			return &vcs.RepoRoot{VCS: cmd, Repo: e.Repo, Root: root}, true
+		}
+		i := strings.LastIndexByte(root, '/')
+		if i < 0 {
+			return nil, false
+		}
+		root = root[:i]
+	}
+}
+
+func (c *repoCache) add(repo *vcs.RepoRoot) {
+	c.entries[repo.Root] = &repoCacheEntry{
+		VCS:      repo.VCS.Cmd,
+		Repo:     repo.Repo,
+		Resolved: time.Now(),
+	}
+	c.dirty = true
+}
+
+// save writes the cache to disk if any entries were added.
+func (c *repoCache) save() error {
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "encoding repo cache")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errors.Wrap(err, "creating repo cache directory")
+	}
+	// Write to a unique temporary file first so concurrent runs never read or write a partial cache.
+	f, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "writing repo cache")
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return errors.Wrap(err, "writing repo cache")
+	}
+	c.dirty = false
+	return nil
+}
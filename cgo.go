@@ -0,0 +1,90 @@
+package gdl
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// A CgoRequirement is the native toolchain a dependency needs to build.
+type CgoRequirement struct {
+	ImportPath       string
+	Root             string
+	CgoFiles         []string // .go files that import "C"
+	NativeFiles      []string `json:",omitempty"` // C, C++, Objective-C, Fortran, assembly and SWIG files
+	LDFLAGS          []string `json:",omitempty"` // flags for the linker
+	PkgConfig        []string `json:",omitempty"` // pkg-config modules
+	MissingPkgConfig []string `json:",omitempty"` // pkg-config modules that do not resolve on the local machine
+}
+
+// needsCToolchain reports whether the package needs a C toolchain to build.
+// Assembly files alone are built by the Go assembler.
+func needsCToolchain(p *Package) bool {
+	for _, files := range [][]string{p.CgoFiles, p.CFiles, p.CXXFiles, p.MFiles, p.FFiles, p.SwigFiles, p.SwigCXXFiles} {
+		if len(files) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgConfig reports whether pkg-config modules exist, only asking pkg-config once per module.
+type pkgConfig struct {
+	path   string // path of pkg-config, empty until first used
+	err    error  // error finding pkg-config
+	exists map[string]bool
+}
+
+// Exists reports whether the module resolves on the local machine.
+func (c *pkgConfig) Exists(module string) bool {
+	if c.exists == nil {
+		c.exists = make(map[string]bool)
+		c.path, c.err = exec.LookPath("pkg-config")
+	}
+	if c.path == "" {
+		return false
+	}
+	exists, ok := c.exists[module]
+	if !ok {
+		exists = exec.Command(c.path, "--exists", module).Run() == nil
+		c.exists[module] = exists
+	}
+	return exists
+}
+
+// CgoRequirements returns the requirements of every dependency that needs a C toolchain,
+// and warnings about the local machine, i.e. a missing pkg-config.
+func CgoRequirements(deps []*Dependency) ([]*CgoRequirement, []string) {
+	pc := &pkgConfig{}
+	var reqs []*CgoRequirement
+	for _, d := range deps {
+		p := d.pkg
+		if !needsCToolchain(p) {
+			continue
+		}
+		r := &CgoRequirement{
+			ImportPath: d.ImportPath,
+			Root:       d.Root,
+			CgoFiles:   p.CgoFiles,
+			LDFLAGS:    p.CgoLDFLAGS,
+		}
+		for _, files := range [][]string{p.CFiles, p.CXXFiles, p.MFiles, p.FFiles, p.SFiles, p.SwigFiles, p.SwigCXXFiles} {
+			r.NativeFiles = append(r.NativeFiles, files...)
+		}
+		for _, module := range p.CgoPkgConfig {
+			// Skip pkg-config options, i.e. --static
+			if strings.HasPrefix(module, "-") {
+				continue
+			}
+			r.PkgConfig = append(r.PkgConfig, module)
+			if !pc.Exists(module) {
+				r.MissingPkgConfig = append(r.MissingPkgConfig, module)
+			}
+		}
+		reqs = append(reqs, r)
+	}
+	if pc.err != nil {
+		return reqs, []string{fmt.Sprintf("pkg-config not found, no pkg-config modules will resolve: %v", pc.err)}
+	}
+	return reqs, nil
+}
@@ -0,0 +1,184 @@
+package gdl
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Checkout describes the state of a local VCS checkout.
+type Checkout struct {
+	Revision string    // current revision
+	Branch   string    // current branch, or tag if not on a branch
+	Time     time.Time // commit time of the current revision
+	Dirty    bool      // working copy has uncommitted changes
+}
+
+// Functions to read the checkout in a directory, by VCS command.
+var checkoutReaders = map[string]func(dir string) (*Checkout, error){
+	"git": gitCheckout,
+	"hg":  hgCheckout,
+	"bzr": bzrCheckout,
+	"svn": svnCheckout,
+}
+
+// readCheckout returns the state of the checkout containing dir.
+// Returns nil if the VCS is not supported.
+func readCheckout(vcsCmd, dir string) (*Checkout, error) {
+	read, ok := checkoutReaders[vcsCmd]
+	if !ok {
+		return nil, nil
+	}
+	return read(dir)
+}
+
+// vcsOutput runs the VCS command in dir and returns its trimmed output.
+func vcsOutput(dir, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "%s %s failed", name, strings.Join(args, " "))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func gitCheckout(dir string) (*Checkout, error) {
+	out, err := vcsOutput(dir, "git", "log", "-1", "--format=%H%n%cI")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		return nil, errors.Errorf("unexpected git log output %q", out)
+	}
+	c := &Checkout{Revision: lines[0]}
+	if c.Time, err = time.Parse(time.RFC3339, lines[1]); err != nil {
+		return nil, errors.Wrap(err, "invalid git commit time")
+	}
+	if c.Branch, err = vcsOutput(dir, "git", "rev-parse", "--abbrev-ref", "HEAD"); err != nil {
+		return nil, err
+	}
+	if c.Branch == "HEAD" {
+		// Detached, use the tag if there is one
+		c.Branch, _ = vcsOutput(dir, "git", "describe", "--tags", "--exact-match")
+	}
+	status, err := vcsOutput(dir, "git", "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	c.Dirty = status != ""
+	return c, nil
+}
+
+func hgCheckout(dir string) (*Checkout, error) {
+	out, err := vcsOutput(dir, "hg", "log", "-r", ".", "--template", "{node}\n{branch}\n{date|rfc3339date}\n{tags}")
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseHgLog(out)
+	if err != nil {
+		return nil, err
+	}
+	status, err := vcsOutput(dir, "hg", "status", "--modified", "--added", "--removed", "--deleted")
+	if err != nil {
+		return nil, err
+	}
+	c.Dirty = status != ""
+	return c, nil
+}
+
+// parseHgLog parses the node, branch, date and tags lines of the hg log output.
+// The tags line is empty, and trimmed from the output, unless the revision is tagged or tip.
+func parseHgLog(out string) (*Checkout, error) {
+	lines := strings.SplitN(out, "\n", 4)
+	if len(lines) < 3 {
+		return nil, errors.Errorf("unexpected hg log output %q", out)
+	}
+	c := &Checkout{Revision: lines[0], Branch: lines[1]}
+	var err error
+	if c.Time, err = time.Parse(time.RFC3339, lines[2]); err != nil {
+		return nil, errors.Wrap(err, "invalid hg commit time")
+	}
+	if len(lines) == 4 {
+		if tags := strings.Fields(lines[3]); c.Branch == "default" && len(tags) > 0 && tags[0] != "tip" {
+			c.Branch = tags[0]
+		}
+	}
+	return c, nil
+}
+
+func bzrCheckout(dir string) (*Checkout, error) {
+	out, err := vcsOutput(dir, "bzr", "version-info", "--check-clean", "--custom",
+		"--template={revision_id}\n{branch_nick}\n{date}\n{clean}\n")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != 4 {
+		return nil, errors.Errorf("unexpected bzr version-info output %q", out)
+	}
+	c := &Checkout{Revision: lines[0], Branch: lines[1], Dirty: lines[3] != "1"}
+	if c.Time, err = time.Parse("2006-01-02 15:04:05 -0700", lines[2]); err != nil {
+		return nil, errors.Wrap(err, "invalid bzr commit time")
+	}
+	return c, nil
+}
+
+func svnCheckout(dir string) (*Checkout, error) {
+	out, err := vcsOutput(dir, "svn", "info")
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkout{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			continue
+		}
+		value := line[i+2:]
+		switch line[:i] {
+		case "Revision":
+			c.Revision = value
+		case "Relative URL", "URL":
+			if c.Branch == "" {
+				c.Branch = svnBranch(value)
+			}
+		case "Last Changed Date":
+			// i.e. 2019-01-02 15:04:05 +0000 (Wed, 02 Jan 2019)
+			if n := strings.Index(value, " ("); n >= 0 {
+				value = value[:n]
+			}
+			if c.Time, err = time.Parse("2006-01-02 15:04:05 -0700", value); err != nil {
+				return nil, errors.Wrap(err, "invalid svn commit time")
+			}
+		}
+	}
+	status, err := vcsOutput(dir, "svn", "status", "--quiet")
+	if err != nil {
+		return nil, err
+	}
+	c.Dirty = status != ""
+	return c, nil
+}
+
+// svnBranch returns the branch or tag name from the conventional layout of an svn URL.
+func svnBranch(url string) string {
+	parts := strings.Split(url, "/")
+	for i, part := range parts {
+		switch part {
+		case "trunk":
+			return part
+		case "branches", "tags":
+			if i+1 < len(parts) {
+				return parts[i+1]
+			}
+		}
+	}
+	return ""
+}
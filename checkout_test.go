@@ -0,0 +1,34 @@
+package gdl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHgLog(t *testing.T) {
+	commitTime := time.Date(2018, 5, 4, 10, 30, 0, 0, time.FixedZone("", 2*60*60))
+	testCases := []struct {
+		name   string
+		out    string
+		branch string
+	}{
+		{name: "untagged", out: "0123abcd\ndefault\n2018-05-04T10:30:00+02:00", branch: "default"},
+		{name: "tip", out: "0123abcd\ndefault\n2018-05-04T10:30:00+02:00\ntip", branch: "default"},
+		{name: "tagged", out: "0123abcd\ndefault\n2018-05-04T10:30:00+02:00\nv1.2.0 tip", branch: "v1.2.0"},
+		{name: "named branch", out: "0123abcd\nstable\n2018-05-04T10:30:00+02:00\nv1.2.0", branch: "stable"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseHgLog(tc.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Revision != "0123abcd" || c.Branch != tc.branch || !c.Time.Equal(commitTime) {
+				t.Errorf("unexpected checkout %+v, want branch %s", c, tc.branch)
+			}
+		})
+	}
+	if _, err := parseHgLog("0123abcd\ndefault"); err == nil {
+		t.Error("expected an error for missing date")
+	}
+}
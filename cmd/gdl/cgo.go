@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+)
+
+// writeCgoRequirements writes the requirements in the output format.
+func writeCgoRequirements(w io.Writer, format string, reqs []*gdl.CgoRequirement) error {
+	header := []string{"ImportPath", "Root", "CgoFiles", "NativeFiles", "LDFLAGS", "PkgConfig", "MissingPkgConfig"}
+	return writeRecords(w, format, header, len(reqs), func(i int) interface{} { return reqs[i] }, func(i int) []string {
+		r := reqs[i]
+		return []string{
+			r.ImportPath,
+			r.Root,
+			strings.Join(r.CgoFiles, " "),
+			strings.Join(r.NativeFiles, " "),
+			strings.Join(r.LDFLAGS, " "),
+			strings.Join(r.PkgConfig, " "),
+			strings.Join(r.MissingPkgConfig, " "),
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const checkUsage = `Usage: gdl check [OPTIONS] [PACKAGES..]
+
+	Check the dependencies against the allow and deny rules of a policy file.
+	Each line of the policy file is a rule in the form:
+
+		allow|deny KIND PATTERN
+
+	where KIND is one of:
+
+		import    import path of the package, the pattern std matches every standard package
+		host      host of the repo, i.e. github.com
+		vcs       version control system of the repo, i.e. git, not checked for dependencies in modules
+		vendored  yes or no
+		license   SPDX identifier of each license, or None or Unknown
+
+	Patterns are globs as with path.Match, a trailing /... also matches everything below.
+	A dependency violates the policy if it matches any deny rule,
+	or if there are allow rules of a kind and it matches none of them.
+	Standard packages are always listed, but are only checked against import rules.
+	Blank lines and lines starting with # are ignored.
+
+	Exits with a non-zero status if any dependency violates the policy.
+
+Examples:
+
+	Check the dependencies of the current package and all sub packages, with a .gdl-policy file of:
+
+		deny import unsafe
+		deny import github.com/evil/...
+		allow host github.com
+		allow host golang.org
+		allow vcs git
+		deny license GPL-*
+		deny license AGPL-*
+
+		gdl check ./...
+
+Options:
+`
+
+func runCheck(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("check", checkUsage)
+	file := fs.String("file", ".gdl-policy", "Path of the policy file.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	policy, err := gdl.ReadPolicy(*file)
+	if err != nil {
+		return err
+	}
+	violations, err := gdl.Check(ctx, options(fs.Args()), policy)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		fmt.Printf("all dependencies follow the %d rules of %s\n", len(policy.Rules), *file)
+		return nil
+	}
+	rows := make([][]string, 1, len(violations)+1)
+	rows[0] = []string{"ImportPath", "Root", "Reason", "Rule", "Pos"}
+	for _, v := range violations {
+		rows = append(rows, []string{v.ImportPath, v.Root, v.Reason, v.Rule, v.Pos})
+	}
+	if err := printTable(os.Stdout, rows); err != nil {
+		return err
+	}
+	return errors.Errorf("dependencies violate the policy: %d", len(violations))
+}
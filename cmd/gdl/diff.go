@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const diffUsage = `Usage: gdl diff [OPTIONS] OLD NEW [PACKAGES..]
+
+	Compare the dependencies of two versions of the packages.
+	Each of OLD and NEW is either a snapshot saved with 'gdl -vcs -format json',
+	or a git ref that is checked out into a temporary worktree and listed.
+	Reports the added, removed and changed dependencies and repos.
+
+Examples:
+
+	Compare the dependencies of the current package and all sub packages between master and HEAD.
+
+		gdl diff master HEAD ./...
+
+	Compare a saved snapshot to the current commit.
+
+		gdl -vcs -format json ./... > deps.json
+		gdl diff deps.json HEAD ./...
+
+Options:
+`
+
+func writeDiff(w io.Writer, diff *gdl.DependencyDiff) error {
+	var b strings.Builder
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(title + ":\n")
+		for _, line := range lines {
+			b.WriteString("\t" + line + "\n")
+		}
+	}
+	changes := func(prefix string, changes []*gdl.Change) []string {
+		lines := make([]string, len(changes))
+		for i, c := range changes {
+			lines[i] = fmt.Sprintf("%s %s %s -> %s", prefix, c.Path, orNone(c.Old), orNone(c.New))
+		}
+		return lines
+	}
+	deps := func(prefix string, deps []*gdl.Dependency) []string {
+		lines := make([]string, len(deps))
+		for i, d := range deps {
+			lines[i] = fmt.Sprintf("%s %s", prefix, d.ImportPath)
+			if rev := d.RevisionOrVersion(); rev != "" {
+				lines[i] += " " + rev
+			}
+		}
+		return lines
+	}
+	section("Added dependencies", deps("+", diff.Added))
+	section("Removed dependencies", deps("-", diff.Removed))
+	section("Changed dependencies", changes("~", diff.Changed))
+	prefixed := func(prefix string, roots []string) []string {
+		lines := make([]string, len(roots))
+		for i, root := range roots {
+			lines[i] = prefix + " " + root
+		}
+		return lines
+	}
+	section("Added repos", prefixed("+", diff.AddedRepos))
+	section("Removed repos", prefixed("-", diff.RemovedRepos))
+	section("Changed repos", changes("~", diff.ChangedRepos))
+	if b.Len() == 0 {
+		b.WriteString("No dependency changes\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// loadDiffSide loads the dependencies from a snapshot file, or from a git ref if no such file exists.
+func loadDiffSide(ctx context.Context, arg string, importPaths []string) ([]*gdl.Dependency, error) {
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		return gdl.ReadSnapshot(arg)
+	}
+	return gdl.ListRef(ctx, options(importPaths), arg)
+}
+
+func runDiff(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("diff", diffUsage)
+	diffFormat := fs.String("format", "text", "Output format, one of text or json.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return errors.New("diff requires an old and new snapshot or git ref")
+	}
+	if *diffFormat != "text" && *diffFormat != "json" {
+		return errors.Errorf("unknown diff format %q", *diffFormat)
+	}
+	paths := fs.Args()[2:]
+	before, err := loadDiffSide(ctx, fs.Arg(0), paths)
+	if err != nil {
+		return err
+	}
+	after, err := loadDiffSide(ctx, fs.Arg(1), paths)
+	if err != nil {
+		return err
+	}
+	diff := gdl.Diff(before, after)
+	if *diffFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(diff)
+	}
+	return writeDiff(os.Stdout, diff)
+}
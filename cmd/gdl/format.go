@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/vcs"
+)
+
+// A column is a single named column of tabular output.
+type column struct {
+	Name  string
+	Value func(d *gdl.Dependency) string
+}
+
+var defaultColumns = []column{
+	{"ImportPath", func(d *gdl.Dependency) string { return d.ImportPath }},
+	{"Vendored", func(d *gdl.Dependency) string { return yesNo(d.Vendored) }},
+	{"Root", func(d *gdl.Dependency) string { return d.Root }},
+	{"VCS", func(d *gdl.Dependency) string { return d.VCS }},
+	{"Repo", func(d *gdl.Dependency) string { return d.Repo }},
+	{"Error", func(d *gdl.Dependency) string { return errorSummary(d.Error) }},
+	{"Direct", func(d *gdl.Dependency) string { return yesNo(d.Direct) }},
+}
+
+var moduleColumns = []column{
+	{"Module", func(d *gdl.Dependency) string { return d.Module }},
+	{"Version", func(d *gdl.Dependency) string { return d.Version }},
+	{"Replace", func(d *gdl.Dependency) string { return d.Replace }},
+	{"Indirect", func(d *gdl.Dependency) string { return yesNo(d.Indirect) }},
+}
+
+var vendorColumns = []column{
+	{"Revision", func(d *gdl.Dependency) string { return d.Revision }},
+	{"Version", func(d *gdl.Dependency) string { return d.Version }},
+	{"Source", func(d *gdl.Dependency) string { return d.Source }},
+	{"VendorTool", func(d *gdl.Dependency) string { return d.VendorTool }},
+}
+
+var checkoutColumns = []column{
+	{"Revision", func(d *gdl.Dependency) string { return d.Revision }},
+	{"Branch", func(d *gdl.Dependency) string { return d.Branch }},
+	{"CommitTime", func(d *gdl.Dependency) string {
+		if d.CommitTime == nil {
+			return ""
+		}
+		return d.CommitTime.Format(time.RFC3339)
+	}},
+	{"Dirty", func(d *gdl.Dependency) string {
+		if d.Dirty == nil {
+			return ""
+		}
+		return yesNo(*d.Dirty)
+	}},
+}
+
+var platformColumns = []column{
+	{"Platforms", func(d *gdl.Dependency) string { return strings.Join(d.Platforms, " ") }},
+}
+
+var licenseColumns = []column{
+	{"License", func(d *gdl.Dependency) string { return d.License }},
+	{"LicenseConfidence", func(d *gdl.Dependency) string { return licenseConfidence(d.LicenseFiles) }},
+}
+
+// licenseConfidence returns the lowest confidence of the classified license files.
+func licenseConfidence(files []*gdl.LicenseFile) string {
+	confidence := 0.0
+	for _, lf := range files {
+		if lf.ID != "" && (confidence == 0 || lf.Confidence < confidence) {
+			confidence = lf.Confidence
+		}
+	}
+	if confidence == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(confidence, 'f', 2, 64)
+}
+
+// appendColumns appends any of the columns that are not already included.
+func appendColumns(cols []column, more ...column) []column {
+	names := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		names[col.Name] = true
+	}
+	// Copy so the appended columns never modify the backing array of cols.
+	cols = append([]column(nil), cols...)
+	for _, col := range more {
+		if !names[col.Name] {
+			names[col.Name] = true
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// errorSummary returns the first line of the error message.
+func errorSummary(err *gdl.PackageError) string {
+	if err == nil {
+		return ""
+	}
+	if n := strings.IndexByte(err.Err, '\n'); n >= 0 {
+		return err.Err[:n]
+	}
+	return err.Err
+}
+
+// A formatter writes the dependencies to w.
+type formatter func(w io.Writer, cols []column, deps []*gdl.Dependency) error
+
+var formatters = map[string]formatter{
+	"table":  writeTable,
+	"json":   writeJSON,
+	"ndjson": writeNDJSON,
+	"csv":    writeCSV,
+	"tsv":    writeTSV,
+}
+
+// rows returns the header row followed by a row per dependency.
+func rows(cols []column, deps []*gdl.Dependency) [][]string {
+	rows := make([][]string, 1, len(deps)+1)
+	rows[0] = make([]string, len(cols))
+	for c, col := range cols {
+		rows[0][c] = col.Name
+	}
+	for _, d := range deps {
+		row := make([]string, len(cols))
+		for c, col := range cols {
+			row[c] = col.Value(d)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeTable(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+	return printTable(w, rows(cols, deps))
+}
+
+func printTable(w io.Writer, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	cols := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for c, col := range row {
+			if l := len(col); l > cols[c] {
+				cols[c] = l + 1
+			}
+		}
+	}
+
+	colFmts := make([]string, len(cols))
+	for i, col := range cols {
+		colFmts[i] = fmt.Sprintf("%%-%ds", col+1)
+	}
+
+	for _, row := range rows {
+		for c, col := range row {
+			if _, err := fmt.Fprintf(w, colFmts[c], col); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+	return writeDelimited(w, ',', rows(cols, deps))
+}
+
+func writeTSV(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+	return writeDelimited(w, '\t', rows(cols, deps))
+}
+
+// writeDelimited writes the rows as delimiter separated values,
+// quoting any value that contains the delimiter, quotes or newlines.
+func writeDelimited(w io.Writer, delim rune, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delim
+	if err := cw.WriteAll(rows); err != nil {
+		return errors.Wrap(err, "writing delimited output")
+	}
+	return nil
+}
+
+// writeRecords writes n records in the output format, as the JSON of each value,
+// or as tabular output with the header followed by the row of each record.
+func writeRecords(w io.Writer, format string, header []string, n int, value func(i int) interface{}, row func(i int) []string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		values := make([]interface{}, n)
+		for i := range values {
+			values[i] = value(i)
+		}
+		return enc.Encode(values)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for i := 0; i < n; i++ {
+			if err := enc.Encode(value(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	rows := make([][]string, 1, n+1)
+	rows[0] = header
+	for i := 0; i < n; i++ {
+		rows = append(rows, row(i))
+	}
+	switch format {
+	case "csv":
+		return writeDelimited(w, ',', rows)
+	case "tsv":
+		return writeDelimited(w, '\t', rows)
+	}
+	return printTable(w, rows)
+}
+
+func writeJSON(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if deps == nil {
+		deps = []*gdl.Dependency{}
+	}
+	return enc.Encode(deps)
+}
+
+func writeNDJSON(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+	enc := json.NewEncoder(w)
+	for _, d := range deps {
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateData is the value the -f template is executed against for each dependency.
+type templateData struct {
+	*gdl.Package
+	Repo   *vcs.RepoRoot
+	Direct bool // imported by the listed packages
+}
+
+// templateFormatter returns a formatter that executes the template text for each dependency,
+// in the same manner as 'go list -f'.
+func templateFormatter(text string) (formatter, error) {
+	tmpl, err := template.New("f").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+	return func(w io.Writer, cols []column, deps []*gdl.Dependency) error {
+		for _, d := range deps {
+			if err := tmpl.Execute(w, templateData{Package: d.Package(), Repo: d.RepoRoot(), Direct: d.Direct}); err != nil {
+				return errors.Wrapf(err, "executing template for %s", d.ImportPath)
+			}
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
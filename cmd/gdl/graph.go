@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const graphUsage = `Usage: gdl graph [OPTIONS] [PACKAGES..]
+
+	Output the import graph of Go packages, from the listed packages to their dependencies.
+	Edges that only exist via test imports are included with -test and drawn dashed.
+
+Examples:
+
+	Output the import graph of the current package and all sub packages as Graphviz DOT.
+
+		gdl graph ./... | dot -Tsvg > deps.svg
+
+	Output the import graph between repos as a Mermaid flowchart.
+
+		gdl graph -format mermaid -repos ./...
+
+Options:
+`
+
+var graphFormatters = map[string]func(w io.Writer, g *gdl.Graph) error{
+	"dot":     writeDOT,
+	"mermaid": writeMermaid,
+	"json":    writeGraphJSON,
+}
+
+func writeDOT(w io.Writer, g *gdl.Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph deps {\n")
+	for _, n := range g.Nodes {
+		if n.Listed {
+			fmt.Fprintf(&b, "\t%q [shape=box];\n", n.ID)
+		} else {
+			fmt.Fprintf(&b, "\t%q;\n", n.ID)
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Test {
+			fmt.Fprintf(&b, "\t%q -> %q [style=dashed];\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", e.From, e.To)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMermaid(w io.Writer, g *gdl.Graph) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	// Mermaid node ids cannot contain most punctuation, so use the node index as the id.
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		label := strings.Replace(n.ID, `"`, "#quot;", -1)
+		if n.Listed {
+			fmt.Fprintf(&b, "\t%s[\"%s\"]\n", ids[n.ID], label)
+		} else {
+			fmt.Fprintf(&b, "\t%s(\"%s\")\n", ids[n.ID], label)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Test {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "\t%s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeGraphJSON(w io.Writer, g *gdl.Graph) error {
+	if g.Nodes == nil {
+		g.Nodes = []*gdl.GraphNode{}
+	}
+	if g.Edges == nil {
+		g.Edges = []*gdl.GraphEdge{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(g)
+}
+
+func runGraph(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("graph", graphUsage)
+	graphFormat := fs.String("format", "dot", "Output format, one of dot, mermaid or json.")
+	byRepo := fs.Bool("repos", false, "Collapse packages into a single node per repo.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	write, ok := graphFormatters[*graphFormat]
+	if !ok {
+		return errors.Errorf("unknown graph format %q", *graphFormat)
+	}
+	opts := options(fs.Args())
+	if !*byRepo {
+		listing, err := gdl.Load(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return write(os.Stdout, gdl.NewGraph(listing, *includeTest))
+	}
+	res, err := gdl.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	roots := make(map[string]string, len(res.Dependencies))
+	for _, d := range res.Dependencies {
+		roots[d.ImportPath] = d.Root
+	}
+	g := gdl.NewGraph(res.Listing, *includeTest).Collapse(func(n *gdl.GraphNode) string {
+		if root, ok := roots[n.ID]; ok {
+			return root
+		}
+		// Listed packages below the current package belong to the current repo
+		if strings.HasPrefix(n.ID, res.Listing.Current) {
+			return res.Listing.Current
+		}
+		return n.ID
+	})
+	return write(os.Stdout, g)
+}
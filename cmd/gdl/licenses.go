@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+)
+
+// writeLicenseSummaries writes the summaries in the output format.
+func writeLicenseSummaries(w io.Writer, format string, summaries []*gdl.LicenseSummary) error {
+	header := []string{"Root", "Repo", "License", "Confidence", "Packages", "Files"}
+	return writeRecords(w, format, header, len(summaries), func(i int) interface{} { return summaries[i] }, func(i int) []string {
+		s := summaries[i]
+		files := make([]string, len(s.Files))
+		for i, lf := range s.Files {
+			files[i] = lf.Path
+		}
+		return []string{
+			s.Root,
+			s.Repo,
+			s.License,
+			licenseConfidence(s.Files),
+			strconv.Itoa(len(s.Packages)),
+			strings.Join(files, " "),
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"github.com/nathanielc/gdl"
+)
+
+const lockUsage = `Usage: gdl lock [OPTIONS] [PACKAGES..]
+
+	Write a lock file recording the repo root, VCS, repo URL and the revision
+	of the local checkout of every repo in GOPATH the packages depend on,
+	and the hash of every vendored package, see 'gdl verify'.
+
+Examples:
+
+	Lock the dependencies of the current package and all sub packages, including test dependencies.
+
+		gdl lock -test ./...
+
+Options:
+`
+
+const restoreUsage = `Usage: gdl restore [OPTIONS]
+
+	Restore the repos recorded in a lock file into GOPATH,
+	cloning any missing repos and checking out the recorded revisions.
+
+Examples:
+
+	Restore the repos recorded in gdl.lock.
+
+		gdl restore
+
+Options:
+`
+
+func runLock(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("lock", lockUsage)
+	file := fs.String("file", "gdl.lock", "Path of the lock file.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	lock, err := gdl.NewLock(ctx, options(fs.Args()))
+	if err != nil {
+		return err
+	}
+	printWarnings(lock.Warnings)
+	return lock.Write(*file)
+}
+
+func runRestore(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("restore", restoreUsage)
+	file := fs.String("file", "gdl.lock", "Path of the lock file.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	lock, err := gdl.ReadLock(*file)
+	if err != nil {
+		return err
+	}
+	return gdl.Restore(ctx, options(nil), lock)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nathanielc/gdl"
+)
+
+func usage() {
+	u := `Usage: gdl [OPTIONS] [COMMAND] [PACKAGES..]
+
+	List dependencies of Go packages.
+	This utility is a light wrapper around the 'go list' command,
+	intended to provide easy access to the dependencies of a project.
+
+Examples:
+
+	List all dependencies of the current package.
+	
+		gdl
+	
+	List all dependencies of the current package and all sub packages, (including any vendored packages).
+
+		gdl ./...
+	
+	List all dependencies of the local sub package ./cmd/foo package.
+
+		gdl ./cmd/foo
+	
+	List all dependencies of the current package and all sub packages skipping any locally vendored packages.
+
+		gdl -no-vendored ./...
+
+	List only the dependencies imported directly by the current package and all sub packages, skipping those only used transitively.
+
+		gdl -direct ./...
+
+	List all dependencies of the current package as JSON.
+
+		gdl -format json
+
+	List all dependencies of the current package and all sub packages with their vendored revisions from the vendor manifest.
+
+		gdl -manifest ./...
+
+	List all dependencies of the current package with the state of their local VCS checkouts.
+
+		gdl -vcs
+
+	List the licenses of all dependencies of the current package and all sub packages grouped by repo.
+
+		gdl -licenses ./...
+
+	List all dependencies of the current package and all sub packages needed by any of the platforms, with the integration build tag.
+
+		gdl -platforms linux/amd64,linux/arm64,darwin/arm64,windows/amd64 -tags integration ./...
+
+	List all dependencies of the current package and all sub packages, failing if any dependency could not be loaded.
+
+		gdl -strict ./...
+
+	List the dependencies of the current package, including the standard library, that need a C toolchain to build,
+	with the pkg-config modules and linker flags they require.
+
+		gdl -std -cgo
+
+	List the direct and transitive dependencies of each package below the current directory,
+	and the packages that use each dependency.
+
+		gdl -matrix ./...
+		gdl -used-by ./...
+
+	List all dependencies of the current package without using the network, resolving repos only from the repo cache
+	and the import paths of well known hosts.
+
+		gdl -offline
+
+	List all dependencies of the current package as CSV.
+
+		gdl -format csv
+
+	List the import path and directory of all dependencies of the current package.
+	The template is executed against the package, as with 'go list -f', with the repo available as .Repo.
+
+		gdl -f '{{.ImportPath}} {{.Dir}} {{.Repo.Root}}'
+
+	Output the import graph of the current package and all sub packages as Graphviz DOT.
+
+		gdl graph ./...
+
+	Explain why github.com/pkg/errors is a dependency of the current package or any sub package.
+
+		gdl why github.com/pkg/errors
+
+	Write a third party notices document for the current package and all sub packages.
+
+		gdl notices -format markdown -o NOTICES.md ./...
+
+	Compare the dependencies of the current package and all sub packages between master and HEAD.
+
+		gdl diff master HEAD ./...
+
+	Lock the revisions of the repos the current package and all sub packages depend on, and restore them later.
+
+		gdl lock ./...
+		gdl restore
+
+	Verify the vendored packages of the current package and all sub packages have not changed since they were locked.
+
+		gdl verify ./...
+
+	List the vendored packages and repos not used by any build or test of the current package and all sub packages.
+
+		gdl unused -test
+
+	Check the dependencies of the current package and all sub packages against the rules of the .gdl-policy file.
+
+		gdl check ./...
+
+Commands:
+
+`
+	fmt.Fprint(os.Stderr, u)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", name, commands[name].Short)
+	}
+	fmt.Fprint(os.Stderr, "\nOptions:\n")
+	flag.PrintDefaults()
+}
+
+var includeStandard = flag.Bool("std", false, "Include dependencies from the standard Go libraries.")
+var includeTest = flag.Bool("test", false, "Include dependencies from tests files.")
+var includeRootDepsOnly = flag.Bool("repo", false, "Include only the first dependency per repo.")
+var directOnly = flag.Bool("direct", false, "Include only the dependencies imported directly by the packages, or their tests with -test.")
+var skipVendored = flag.Bool("no-vendored", false, "Skip any packages that are vendored below the current package.")
+var listTemplate = flag.String("f", "", "Output each dependency using the given text/template, as with 'go list -f'. Overrides -format.")
+var includeManifest = flag.Bool("manifest", false, "Include the revision, version and source of vendored dependencies from the vendor manifest, and the tool managing it.")
+var includeCheckouts = flag.Bool("vcs", false, "Include the revision, branch or tag, commit time and dirty state of the local checkout of each dependency.")
+var includeLicenses = flag.Bool("license", false, "Include the license of each dependency, classified from the license files of the dependency.")
+var licenseSummary = flag.Bool("licenses", false, "Output a summary of the licenses of the dependencies grouped by repo.")
+var buildPlatforms = flag.String("platforms", "", "Comma separated list of GOOS/GOARCH platforms to list the dependencies for, reporting the union of their dependencies.")
+var buildTags = flag.String("tags", "", "Comma separated list of build tags to list the dependencies with.")
+var strict = flag.Bool("strict", false, "Fail with the full errors if any package or dependency could not be loaded.")
+var cgoReport = flag.Bool("cgo", false, "Output the dependencies that need a C toolchain, with their pkg-config modules and linker flags.")
+var matrix = flag.Bool("matrix", false, "Output the direct and transitive dependencies of each listed package.")
+var usedBy = flag.Bool("used-by", false, "Output the listed packages that use each dependency, directly or transitively.")
+var offline = flag.Bool("offline", false, "Never use the network to resolve repos, only the repo cache and well known hosts are used.")
+var cachePath = flag.String("cache", gdl.DefaultCachePath(), "Path of the repo cache file, an empty path disables the on disk cache.")
+var cacheTTL = flag.Duration("cache-ttl", 7*24*time.Hour, "Duration cached repos are used before they are resolved again.")
+var concurrency = flag.Int("concurrency", 8, "Maximum number of repos to resolve over the network at once.")
+var format = flag.String("format", "table", "Output format, one of table, json, ndjson (newline delimited JSON), csv or tsv.")
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	write, ok := formatters[*format]
+	if !ok {
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *listTemplate != "" {
+		var err error
+		write, err = templateFormatter(*listTemplate)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	args := flag.Args()
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			if err := cmd.Run(ctx, args[1:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	opts := options(args)
+	opts.RootOnly = *includeRootDepsOnly
+	opts.Checkouts = *includeCheckouts
+	opts.Cgo = *cgoReport
+	opts.Licenses = *includeLicenses || *licenseSummary
+	res, err := gdl.List(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printWarnings(res.Warnings)
+	dependencies := res.Dependencies
+	if *licenseSummary {
+		if err := writeLicenseSummaries(os.Stdout, *format, gdl.SummarizeLicenses(dependencies)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *cgoReport {
+		reqs, warnings := gdl.CgoRequirements(dependencies)
+		printWarnings(warnings)
+		if err := writeCgoRequirements(os.Stdout, *format, reqs); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *matrix || *usedBy {
+		m := gdl.Matrix(res.Listing, dependencies, *includeTest)
+		if *usedBy {
+			err = writeUsers(os.Stdout, *format, gdl.Users(m, dependencies))
+		} else {
+			err = writeMatrix(os.Stdout, *format, m)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cols := defaultColumns
+	if res.Listing.Modules != nil {
+		cols = appendColumns(cols, moduleColumns...)
+	}
+	if *includeManifest {
+		cols = appendColumns(cols, vendorColumns...)
+	}
+	if *buildPlatforms != "" {
+		cols = appendColumns(cols, platformColumns...)
+	}
+	if *includeCheckouts {
+		cols = appendColumns(cols, checkoutColumns...)
+	}
+	if *includeLicenses {
+		cols = appendColumns(cols, licenseColumns...)
+	}
+	if err := write(os.Stdout, cols, dependencies); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printWarnings logs the warnings of the library to standard error.
+func printWarnings(warnings []string) {
+	for _, w := range warnings {
+		log.Print(w)
+	}
+}
+
+// baseOptions are the options not set by any flag, i.e. the directory, go command and resolver of the tests.
+var baseOptions gdl.Options
+
+// options returns the options from the global flags to list the import paths.
+func options(importPaths []string) gdl.Options {
+	opts := baseOptions
+	opts.ImportPaths = importPaths
+	opts.Standard = *includeStandard
+	opts.Tests = *includeTest
+	opts.SkipVendored = *skipVendored
+	opts.DirectOnly = *directOnly
+	opts.Tags = *buildTags
+	opts.Strict = *strict
+	opts.Offline = *offline
+	opts.CachePath = *cachePath
+	opts.CacheTTL = *cacheTTL
+	opts.Concurrency = *concurrency
+	if *buildPlatforms != "" {
+		opts.Platforms = strings.Split(*buildPlatforms, ",")
+	}
+	return opts
+}
+
+// A command is a gdl sub command.
+type command struct {
+	Short string // short description of the command
+	Run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"graph":   {"Output the import graph as DOT, Mermaid or JSON.", runGraph},
+	"why":     {"Explain how packages are imported.", runWhy},
+	"diff":    {"Compare the dependencies of two git refs or snapshots.", runDiff},
+	"notices": {"Write a third party notices document with the license of every repo.", runNotices},
+	"lock":    {"Write a lock file with the revision of every repo in GOPATH.", runLock},
+	"restore": {"Restore the repos in GOPATH to the revisions in a lock file.", runRestore},
+	"verify":  {"Verify the vendored packages against the hashes in a lock file.", runVerify},
+	"unused":  {"List the vendored packages and repos that are not dependencies.", runUnused},
+	"check":   {"Check the dependencies against the allow and deny rules of a policy file.", runCheck},
+}
+
+// newCommandFlagSet returns a flag set for the named command.
+// The usage text is printed above the command flags.
+func newCommandFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseCommandFlags parses the command flags from args.
+// The global options are also accepted after the command name,
+// unless the command defines a flag with the same name.
+func parseCommandFlags(fs *flag.FlagSet, args []string) error {
+	flag.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	return fs.Parse(args)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathanielc/gdl"
+	"github.com/nathanielc/gdl/internal/gdltest"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata/golden.")
+
+// gopath is the absolute path of the GOPATH the go command recordings were made in.
+var gopath string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	var err error
+	gopath, err = filepath.Abs(filepath.Join("..", "..", "testdata", "gopath"))
+	if err != nil {
+		panic(err)
+	}
+	baseOptions = gdl.Options{
+		Dir:      filepath.Join(gopath, "src", "example.com", "app"),
+		Runner:   gdltest.Go{Testdata: filepath.Join("..", "..", "testdata"), GOPATH: gopath},
+		Resolver: gdltest.Repos,
+	}
+	// Never use the repo cache of the user
+	if err := flag.Set("cache", ""); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// setFlags sets the global flags, restoring their values when the test completes.
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		f := flag.Lookup(name)
+		old := f.Value.String()
+		if err := f.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Value.Set(old) })
+	}
+}
+
+// checkGolden compares the output to the golden file, with the GOPATH replaced by $GOPATH.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	got = bytes.Replace(got, []byte(gopath), []byte("$GOPATH"), -1)
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unexpected output, run with -update to update the golden file %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// list lists the dependencies of the packages in testdata/gopath with the global flags.
+func list(t *testing.T, importPaths ...string) gdl.Result {
+	t.Helper()
+	res, err := gdl.List(context.Background(), options(importPaths))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestOptions(t *testing.T) {
+	setFlags(t, map[string]string{
+		"std":         "true",
+		"test":        "true",
+		"no-vendored": "true",
+		"direct":      "true",
+		"tags":        "integration",
+		"strict":      "true",
+		"offline":     "true",
+		"cache":       "/tmp/repos.json",
+		"cache-ttl":   "1h",
+		"concurrency": "2",
+		"platforms":   "linux/amd64,darwin/arm64",
+	})
+	want := baseOptions
+	want.ImportPaths = []string{"./..."}
+	want.Standard = true
+	want.Tests = true
+	want.SkipVendored = true
+	want.DirectOnly = true
+	want.Tags = "integration"
+	want.Strict = true
+	want.Offline = true
+	want.CachePath = "/tmp/repos.json"
+	want.CacheTTL = time.Hour
+	want.Concurrency = 2
+	want.Platforms = []string{"linux/amd64", "darwin/arm64"}
+	if got := options([]string{"./..."}); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected options\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	deps := list(t, "./...").Dependencies
+	// Values that must be quoted in csv and tsv output
+	deps = append(deps, &gdl.Dependency{
+		ImportPath: `example.com/"quoted"`,
+		Root:       "example.com/comma,root",
+		VCS:        "Git",
+		Repo:       "https://example.com/tab\troot",
+	})
+	for _, name := range []string{"table", "csv", "tsv"} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := formatters[name](&b, defaultColumns, deps); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "list."+name, b.Bytes())
+		})
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	setFlags(t, map[string]string{"std": "true"})
+	write, err := templateFormatter(`{{.ImportPath}} {{.Repo.Root}} {{.Direct}} {{join .Imports ","}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := write(&b, nil, list(t, "./...").Dependencies); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "template.txt", b.Bytes())
+}
+
+func TestGraphFormatters(t *testing.T) {
+	setFlags(t, map[string]string{"test": "true"})
+	listing, err := gdl.Load(context.Background(), options([]string{"./..."}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := gdl.NewGraph(listing, true)
+	for name, file := range map[string]string{"dot": "graph.dot", "mermaid": "graph.mmd"} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := graphFormatters[name](&b, g); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, file, b.Bytes())
+		})
+	}
+}
+
+func TestPrintImportChains(t *testing.T) {
+	setFlags(t, map[string]string{"std": "true", "test": "true"})
+	listing, err := gdl.Load(context.Background(), options([]string{"./..."}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := gdl.NewGraph(listing, true)
+	var b bytes.Buffer
+	for _, tc := range []struct {
+		target string
+		max    int
+	}{
+		{"github.com/pkg/errors", 1},
+		{"errors", 0},
+		{"net/http", 1},
+	} {
+		if err := printImportChains(&b, tc.target, g.ImportChains(tc.target, tc.max)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkGolden(t, "why.txt", b.Bytes())
+}
+
+func TestWriteDiff(t *testing.T) {
+	before := []*gdl.Dependency{
+		{ImportPath: "github.com/foo/bar", Root: "github.com/foo/bar", Revision: "aaa"},
+		{ImportPath: "github.com/foo/bar/baz", Root: "github.com/foo/bar", Revision: "aaa"},
+		{ImportPath: "github.com/old/gone", Root: "github.com/old/gone", Revision: "ccc"},
+	}
+	after := []*gdl.Dependency{
+		{ImportPath: "github.com/foo/bar", Root: "github.com/foo/bar", Revision: "bbb"},
+		{ImportPath: "github.com/new/mod", Root: "github.com/new/mod", Module: "github.com/new/mod", Version: "v1.2.0"},
+	}
+	var b bytes.Buffer
+	if err := writeDiff(&b, gdl.Diff(before, after)); err != nil {
+		t.Fatal(err)
+	}
+	b.WriteString("\n")
+	if err := writeDiff(&b, gdl.Diff(after, after)); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "diff.txt", b.Bytes())
+}
+
+func TestWriteRecords(t *testing.T) {
+	res := list(t, "./...")
+	m := gdl.Matrix(res.Listing, res.Dependencies, false)
+	var b bytes.Buffer
+	for _, format := range []string{"table", "csv", "ndjson"} {
+		if err := writeUsers(&b, format, gdl.Users(m, res.Dependencies)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkGolden(t, "used_by.txt", b.Bytes())
+
+	// Empty output is an empty JSON array, not null
+	for _, write := range []func() error{
+		func() error { return writeMatrix(&b, "json", nil) },
+		func() error { return writeUsers(&b, "json", nil) },
+		func() error { return writeCgoRequirements(&b, "json", nil) },
+		func() error { return writeLicenseSummaries(&b, "json", nil) },
+	} {
+		b.Reset()
+		if err := write(); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(b.String()); got != "[]" {
+			t.Errorf("expected an empty array, got %s", got)
+		}
+	}
+}
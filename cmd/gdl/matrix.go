@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+)
+
+// writeMatrix writes the dependencies of each listed package in the output format.
+func writeMatrix(w io.Writer, format string, matrix []*gdl.PackageDeps) error {
+	header := []string{"Package", "Direct", "Transitive"}
+	return writeRecords(w, format, header, len(matrix), func(i int) interface{} { return matrix[i] }, func(i int) []string {
+		pd := matrix[i]
+		return []string{
+			pd.ImportPath,
+			strings.Join(pd.Direct, " "),
+			strings.Join(pd.Transitive, " "),
+		}
+	})
+}
+
+// writeUsers writes the listed packages that use each dependency in the output format.
+func writeUsers(w io.Writer, format string, users []*gdl.DependencyUsers) error {
+	header := []string{"ImportPath", "Root", "Direct", "Transitive"}
+	return writeRecords(w, format, header, len(users), func(i int) interface{} { return users[i] }, func(i int) []string {
+		u := users[i]
+		return []string{
+			u.ImportPath,
+			u.Root,
+			strings.Join(u.Direct, " "),
+			strings.Join(u.Transitive, " "),
+		}
+	})
+}
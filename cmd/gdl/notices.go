@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const noticesUsage = `Usage: gdl notices [OPTIONS] [PACKAGES..]
+
+	Write a third party notices document, listing the repo, revision and
+	full license texts of every repo the packages depend on.
+
+Examples:
+
+	Write the notices for the current package and all sub packages as Markdown.
+
+		gdl notices -format markdown -o NOTICES.md ./...
+
+Options:
+`
+
+func runNotices(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("notices", noticesUsage)
+	noticesFormat := fs.String("format", "text", "Output format, one of text or markdown.")
+	output := fs.String("o", "", "Write the notices to the file instead of stdout.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	var write func(w io.Writer, summaries []*gdl.LicenseSummary) error
+	switch *noticesFormat {
+	case "text":
+		write = writeTextNotices
+	case "markdown":
+		write = writeMarkdownNotices
+	default:
+		return errors.Errorf("unknown notices format %q", *noticesFormat)
+	}
+	opts := options(fs.Args())
+	opts.Checkouts, opts.Licenses = true, true
+	res, err := gdl.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	printWarnings(res.Warnings)
+	summaries := gdl.SummarizeLicenses(res.Dependencies)
+
+	if *output == "" {
+		return write(os.Stdout, summaries)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return errors.Wrap(err, "creating notices file")
+	}
+	if err := write(f, summaries); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readLicenseText returns the contents of the license file with consistent line endings.
+func readLicenseText(lf *gdl.LicenseFile) (string, error) {
+	data, err := ioutil.ReadFile(lf.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading license file %s", lf.Path)
+	}
+	return strings.TrimSpace(strings.Replace(string(data), "\r\n", "\n", -1)), nil
+}
+
+func writeTextNotices(w io.Writer, summaries []*gdl.LicenseSummary) error {
+	var b strings.Builder
+	b.WriteString("THIRD PARTY NOTICES\n\n")
+	b.WriteString("This document lists the third party software used along with their licenses.\n")
+	rule := strings.Repeat("=", 80)
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "\n%s\n%s\n\n", rule, s.Root)
+		if s.Repo != "" {
+			fmt.Fprintf(&b, "Repo:     %s\n", s.Repo)
+		}
+		if s.Revision != "" {
+			fmt.Fprintf(&b, "Revision: %s\n", s.Revision)
+		}
+		fmt.Fprintf(&b, "License:  %s\n", s.License)
+		for _, lf := range s.Files {
+			text, err := readLicenseText(lf)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "\n%s\n\n%s\n", strings.Repeat("-", 80), text)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownNotices(w io.Writer, summaries []*gdl.LicenseSummary) error {
+	var b strings.Builder
+	b.WriteString("# Third Party Notices\n\n")
+	b.WriteString("This document lists the third party software used along with their licenses.\n")
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Root)
+		if s.Repo != "" {
+			fmt.Fprintf(&b, "* Repo: %s\n", s.Repo)
+		}
+		if s.Revision != "" {
+			fmt.Fprintf(&b, "* Revision: `%s`\n", s.Revision)
+		}
+		fmt.Fprintf(&b, "* License: %s\n", s.License)
+		for _, lf := range s.Files {
+			text, err := readLicenseText(lf)
+			if err != nil {
+				return err
+			}
+			// Use a fence longer than any backtick run in the text
+			fence := "```"
+			for strings.Contains(text, fence) {
+				fence += "`"
+			}
+			fmt.Fprintf(&b, "\n%s\n%s\n%s\n", fence, text, fence)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
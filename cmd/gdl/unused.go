@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nathanielc/gdl"
+)
+
+const unusedUsage = `Usage: gdl unused [OPTIONS] [PACKAGES..]
+
+	Find the vendored packages that are not a dependency of any of the packages,
+	and the vendored repos none of whose packages are a dependency.
+	Test dependencies are only counted with -test.
+	By default all packages below the current directory are checked, as with ./...
+
+Examples:
+
+	List the vendored packages not used by any build or test of the current package and all sub packages.
+
+		gdl unused -test
+
+	Remove the unused vendored packages and repos.
+
+		gdl unused -test -prune | xargs rm -r
+
+Options:
+`
+
+func runUnused(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("unused", unusedUsage)
+	prune := fs.Bool("prune", false, "Output the list of paths to remove to prune the unused packages, one per line.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	opts := options(fs.Args())
+	if len(opts.ImportPaths) == 0 {
+		opts.ImportPaths = []string{"./..."}
+	}
+	unused, warnings, err := gdl.Unused(ctx, opts)
+	if err != nil {
+		return err
+	}
+	printWarnings(warnings)
+	if *prune {
+		prunes, err := gdl.PruneList(".", unused)
+		if err != nil {
+			return err
+		}
+		for _, p := range prunes {
+			fmt.Println(p)
+		}
+		return nil
+	}
+	rows := make([][]string, 1, len(unused)+1)
+	rows[0] = []string{"ImportPath", "Root", "Dir", "RepoUnused"}
+	for _, u := range unused {
+		rows = append(rows, []string{u.ImportPath, u.Root, u.Dir, yesNo(u.RepoUnused)})
+	}
+	return printTable(os.Stdout, rows)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const verifyUsage = `Usage: gdl verify [OPTIONS] [PACKAGES..]
+
+	Verify the vendored packages against the hashes recorded in a lock file with 'gdl lock'.
+	Reports every vendored package that does not match the lock, as one of:
+
+		tampered  the package differs from the lock
+		patched   the package differs from the lock and has uncommitted changes in git
+		missing   the package is in the lock but not vendored
+		extra     the package is vendored but not in the lock
+
+	Exits with a non-zero status if any package does not match.
+	Use the same options as when the lock file was written.
+
+Examples:
+
+	Verify the vendored dependencies of the current package and all sub packages.
+
+		gdl verify ./...
+
+Options:
+`
+
+func runVerify(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("verify", verifyUsage)
+	file := fs.String("file", "gdl.lock", "Path of the lock file.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	lock, err := gdl.ReadLock(*file)
+	if err != nil {
+		return err
+	}
+	problems, err := gdl.Verify(ctx, options(fs.Args()), lock)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Printf("all %d vendored packages verified\n", len(lock.Vendor))
+		return nil
+	}
+	rows := make([][]string, 1, len(problems)+1)
+	rows[0] = []string{"Status", "ImportPath", "Dir"}
+	for _, p := range problems {
+		rows = append(rows, []string{p.Status, p.ImportPath, p.Dir})
+	}
+	if err := printTable(os.Stdout, rows); err != nil {
+		return err
+	}
+	return errors.Errorf("vendored packages do not match the lock: %d", len(problems))
+}
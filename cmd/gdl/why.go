@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nathanielc/gdl"
+	"github.com/pkg/errors"
+)
+
+const whyUsage = `Usage: gdl why [OPTIONS] IMPORTPATH..
+
+	Explain why packages are dependencies, by printing the shortest import chain
+	from the listed packages to each package.
+	Imports that only exist via test files are marked with (test).
+
+Examples:
+
+	Explain why github.com/pkg/errors is a dependency of the current package or any sub package.
+
+		gdl why github.com/pkg/errors
+
+	Print every import chain from the ./cmd/foo package to the fmt package.
+
+		gdl why -all -max 0 -from ./cmd/foo fmt
+
+Options:
+`
+
+func printImportChains(w io.Writer, target string, chains []gdl.ImportChain) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", target)
+	if len(chains) == 0 {
+		b.WriteString("(no import chain found)\n")
+	}
+	for i, chain := range chains {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(chain.Start + "\n")
+		for _, e := range chain.Imports {
+			if e.Test {
+				fmt.Fprintf(&b, "%s (test)\n", e.To)
+			} else {
+				fmt.Fprintf(&b, "%s\n", e.To)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func runWhy(ctx context.Context, args []string) error {
+	fs := newCommandFlagSet("why", whyUsage)
+	all := fs.Bool("all", false, "Print every import chain instead of only the shortest.")
+	max := fs.Int("max", 100, "Maximum number of import chains to print per package with -all, 0 prints every chain.")
+	from := fs.String("from", "./...", "Comma separated list of the packages to start the import chains from.")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return err
+	}
+	targets := fs.Args()
+	if len(targets) == 0 {
+		return errors.New("why requires at least one import path")
+	}
+	// Standard and test dependencies are always loaded so any import can be explained.
+	opts := options(strings.Split(*from, ","))
+	opts.Standard, opts.Tests = true, true
+	listing, err := gdl.Load(ctx, opts)
+	if err != nil {
+		return err
+	}
+	g := gdl.NewGraph(listing, true)
+	limit := 1
+	if *all {
+		limit = *max
+	}
+	for i, target := range targets {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := printImportChains(os.Stdout, target, g.ImportChains(target, limit)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
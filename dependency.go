@@ -0,0 +1,101 @@
+package gdl
+
+import (
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// A Dependency is a single dependency of the listed packages along with the repo it belongs to.
+type Dependency struct {
+	ImportPath   string
+	Name         string `json:",omitempty"`
+	Dir          string `json:",omitempty"`
+	Standard     bool   `json:",omitempty"`
+	Vendored     bool
+	Direct       bool            // imported by the listed packages or our own code, otherwise only a transitive dependency
+	Root         string          // root import path of the repo
+	VCS          string          // name of the version control system of the repo
+	Repo         string          // repo url
+	Module       string          `json:",omitempty"` // path of the module containing the package
+	Version      string          `json:",omitempty"` // selected version of the module
+	Replace      string          `json:",omitempty"` // replacement of the module
+	Indirect     bool            `json:",omitempty"` // module is only an indirect dependency of the main module
+	Revision     string          `json:",omitempty"` // vendored revision
+	Source       string          `json:",omitempty"` // alternate location the dependency was vendored from
+	VendorTool   string          `json:",omitempty"` // tool managing the vendored dependency
+	Branch       string          `json:",omitempty"` // branch, or tag, of the local checkout
+	CommitTime   *time.Time      `json:",omitempty"` // commit time of the local checkout revision
+	Dirty        *bool           `json:",omitempty"` // local checkout has uncommitted changes
+	Platforms    []string        `json:",omitempty"` // GOOS/GOARCH platforms that need the dependency
+	License      string          `json:",omitempty"` // SPDX identifiers of the licenses
+	LicenseFiles []*LicenseFile  `json:",omitempty"`
+	Incomplete   bool            `json:",omitempty"`
+	Error        *PackageError   `json:",omitempty"`
+	DepsErrors   []*PackageError `json:",omitempty"`
+
+	pkg  *Package
+	repo *vcs.RepoRoot
+}
+
+func newDependency(pkg *Package, repo *vcs.RepoRoot) *Dependency {
+	d := &Dependency{
+		ImportPath: pkg.ImportPath,
+		Name:       pkg.Name,
+		Dir:        pkg.Dir,
+		Standard:   pkg.Standard,
+		Vendored:   pkg.Vendored,
+		Root:       repo.Root,
+		VCS:        repo.VCS.Name,
+		Repo:       repo.Repo,
+		Incomplete: pkg.Incomplete,
+		Error:      pkg.Error,
+		DepsErrors: pkg.DepsErrors,
+		pkg:        pkg,
+		repo:       repo,
+	}
+	if m := pkg.Module; m != nil {
+		d.Module = m.Path
+		d.Version = m.Version
+		d.Indirect = m.Indirect
+		if m.Replace != nil {
+			d.Replace = m.Replace.String()
+		}
+	}
+	return d
+}
+
+// Package returns the package of the dependency as listed by 'go list'.
+func (d *Dependency) Package() *Package {
+	return d.pkg
+}
+
+// RepoRoot returns the repo the dependency belongs to.
+func (d *Dependency) RepoRoot() *vcs.RepoRoot {
+	return d.repo
+}
+
+// setVendoredProject records the vendored project details on the dependency.
+func (d *Dependency) setVendoredProject(tool string, p *VendoredProject) {
+	d.VendorTool = tool
+	d.Revision = p.Revision
+	d.Source = p.Source
+	if d.Version == "" {
+		d.Version = p.Version
+	}
+}
+
+// setCheckout records the state of the local checkout on the dependency.
+func (d *Dependency) setCheckout(c *Checkout) {
+	d.Revision = c.Revision
+	d.Branch = c.Branch
+	t, dirty := c.Time, c.Dirty
+	d.CommitTime = &t
+	d.Dirty = &dirty
+}
+
+// setLicenseFiles records the license files found for the dependency.
+func (d *Dependency) setLicenseFiles(files []*LicenseFile) {
+	d.LicenseFiles = files
+	d.License = licenseName(licenseIDs(files), len(files))
+}
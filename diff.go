@@ -0,0 +1,200 @@
+package gdl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A DependencyDiff is the difference between two sets of dependencies.
+type DependencyDiff struct {
+	Added        []*Dependency
+	Removed      []*Dependency
+	Changed      []*Change
+	AddedRepos   []string
+	RemovedRepos []string
+	ChangedRepos []*Change
+}
+
+// A Change is a change of the revision, or version, of a dependency or repo.
+type Change struct {
+	Path string // import path of the dependency, or root of the repo
+	Old  string
+	New  string
+}
+
+// RevisionOrVersion returns the revision of the dependency, or its version if the revision is unknown.
+func (d *Dependency) RevisionOrVersion() string {
+	if d.Revision != "" {
+		return d.Revision
+	}
+	return d.Version
+}
+
+// revisionChanged reports whether the revision changed.
+// A revision missing on either side is unknown rather than changed,
+// i.e. a snapshot saved without -vcs has no revisions for dependencies in GOPATH.
+func revisionChanged(old, new string) bool {
+	return old != "" && new != "" && old != new
+}
+
+// Diff compares the dependencies from before and after.
+func Diff(before, after []*Dependency) *DependencyDiff {
+	diff := &DependencyDiff{}
+	oldDeps := make(map[string]*Dependency, len(before))
+	oldRepos := make(map[string]string)
+	for _, d := range before {
+		oldDeps[d.ImportPath] = d
+		if _, ok := oldRepos[d.Root]; !ok || oldRepos[d.Root] == "" {
+			oldRepos[d.Root] = d.RevisionOrVersion()
+		}
+	}
+	newDeps := make(map[string]*Dependency, len(after))
+	newRepos := make(map[string]string)
+	for _, d := range after {
+		newDeps[d.ImportPath] = d
+		if _, ok := newRepos[d.Root]; !ok || newRepos[d.Root] == "" {
+			newRepos[d.Root] = d.RevisionOrVersion()
+		}
+	}
+
+	for _, d := range after {
+		od, ok := oldDeps[d.ImportPath]
+		if !ok {
+			diff.Added = append(diff.Added, d)
+		} else if revisionChanged(od.RevisionOrVersion(), d.RevisionOrVersion()) {
+			diff.Changed = append(diff.Changed, &Change{Path: d.ImportPath, Old: od.RevisionOrVersion(), New: d.RevisionOrVersion()})
+		}
+	}
+	for _, d := range before {
+		if _, ok := newDeps[d.ImportPath]; !ok {
+			diff.Removed = append(diff.Removed, d)
+		}
+	}
+	for root, rev := range newRepos {
+		oldRev, ok := oldRepos[root]
+		if !ok {
+			diff.AddedRepos = append(diff.AddedRepos, root)
+		} else if revisionChanged(oldRev, rev) {
+			diff.ChangedRepos = append(diff.ChangedRepos, &Change{Path: root, Old: oldRev, New: rev})
+		}
+	}
+	for root := range oldRepos {
+		if _, ok := newRepos[root]; !ok {
+			diff.RemovedRepos = append(diff.RemovedRepos, root)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].ImportPath < diff.Added[j].ImportPath })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].ImportPath < diff.Removed[j].ImportPath })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Path < diff.Changed[j].Path })
+	sort.Strings(diff.AddedRepos)
+	sort.Strings(diff.RemovedRepos)
+	sort.Slice(diff.ChangedRepos, func(i, j int) bool { return diff.ChangedRepos[i].Path < diff.ChangedRepos[j].Path })
+	return diff
+}
+
+// ReadSnapshot reads the dependencies saved as a JSON array or as newline delimited JSON.
+func ReadSnapshot(path string) ([]*Dependency, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading snapshot")
+	}
+	var deps []*Dependency
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &deps); err != nil {
+			return nil, errors.Wrapf(err, "invalid snapshot %s", path)
+		}
+		return deps, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		d := &Dependency{}
+		if err := dec.Decode(d); err != nil {
+			return nil, errors.Wrapf(err, "invalid snapshot %s", path)
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
+
+// gitOutput runs git in dir and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", errors.Errorf("git %s failed: %s", strings.Join(args, " "), bytes.TrimSpace(ee.Stderr))
+		}
+		return "", errors.Wrapf(err, "git %s failed", strings.Join(args, " "))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListRef lists the dependencies of the packages as of the git ref,
+// by checking out the ref into a temporary worktree of the repo of the directory.
+func ListRef(ctx context.Context, opts Options, ref string) (deps []*Dependency, err error) {
+	g, err := opts.goTool()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := gitOutput(g.Dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	modules, err := moduleMode(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	current, err := listPackages(ctx, g, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "listing current package")
+	}
+	if len(current) != 1 {
+		return nil, errors.New("extra results getting current package")
+	}
+
+	tmp, err := ioutil.TempDir("", "gdl-diff")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	worktree := filepath.Join(tmp, "worktree")
+	if !modules {
+		// Outside of module mode the worktree must be in a GOPATH to keep its import path
+		// and vendor directory.
+		top := strings.TrimSuffix(strings.TrimSuffix(current[0], strings.TrimSuffix(prefix, "/")), "/")
+		worktree = filepath.Join(tmp, "src", filepath.FromSlash(top))
+		gopath, err := g.run(ctx, "env", "GOPATH")
+		if err != nil {
+			return nil, errors.Wrap(err, "go env cmd failed")
+		}
+		opts.Env = append(append([]string(nil), opts.Env...), "GOPATH="+tmp+string(filepath.ListSeparator)+strings.TrimSpace(string(gopath)))
+	}
+	if _, err := gitOutput(g.Dir, "worktree", "add", "--detach", worktree, ref); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, rerr := gitOutput(g.Dir, "worktree", "remove", "--force", worktree); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	opts.Dir = filepath.Join(worktree, filepath.FromSlash(prefix))
+	opts.Checkouts = true
+	res, err := List(ctx, opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing dependencies of %s", ref)
+	}
+	return res.Dependencies, nil
+}
@@ -0,0 +1,28 @@
+package gdl_test
+
+import (
+	"testing"
+
+	"github.com/nathanielc/gdl"
+)
+
+func TestDiffUnknownRevision(t *testing.T) {
+	before := []*gdl.Dependency{
+		{ImportPath: "github.com/foo/bar", Root: "github.com/foo/bar"},
+		{ImportPath: "github.com/foo/baz", Root: "github.com/foo/baz", Revision: "aaa"},
+	}
+	after := []*gdl.Dependency{
+		{ImportPath: "github.com/foo/bar", Root: "github.com/foo/bar", Revision: "bbb"},
+		{ImportPath: "github.com/foo/baz", Root: "github.com/foo/baz", Revision: "ccc"},
+	}
+	diff := gdl.Diff(before, after)
+	if len(diff.Changed) != 1 || diff.Changed[0].Path != "github.com/foo/baz" {
+		t.Errorf("unexpected changed dependencies %+v", diff.Changed)
+	}
+	if len(diff.ChangedRepos) != 1 || diff.ChangedRepos[0].Path != "github.com/foo/baz" {
+		t.Errorf("unexpected changed repos %+v", diff.ChangedRepos)
+	}
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("unexpected added %v or removed %v dependencies", diff.Added, diff.Removed)
+	}
+}
@@ -0,0 +1,99 @@
+package gdl
+
+import (
+	"sort"
+)
+
+// A Graph is an import graph between packages, or between repos.
+type Graph struct {
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+}
+
+// A GraphNode is a single package or repo in a graph.
+type GraphNode struct {
+	ID       string
+	Listed   bool `json:",omitempty"` // node is one of the listed packages
+	Standard bool `json:",omitempty"`
+	Vendored bool `json:",omitempty"`
+}
+
+// A GraphEdge is an import from one node to another.
+type GraphEdge struct {
+	From string
+	To   string
+	Test bool `json:",omitempty"` // edge only exists via test imports
+}
+
+// NewGraph returns the import graph from the listed packages to their dependencies.
+// Test imports of the listed packages are included if tests is true.
+func NewGraph(l *Listing, tests bool) *Graph {
+	g := &Graph{}
+	pkgs := make(map[string]*Package, len(l.Packages)+len(l.Deps))
+	for _, pkg := range l.Packages {
+		pkgs[pkg.ImportPath] = pkg
+		g.Nodes = append(g.Nodes, &GraphNode{ID: pkg.ImportPath, Listed: true})
+	}
+	for _, pkg := range l.Deps {
+		if _, ok := pkgs[pkg.ImportPath]; ok {
+			continue
+		}
+		pkgs[pkg.ImportPath] = pkg
+		g.Nodes = append(g.Nodes, &GraphNode{ID: pkg.ImportPath, Standard: pkg.Standard, Vendored: pkg.Vendored})
+	}
+	for _, n := range g.Nodes {
+		// Only the tests of the listed packages are relevant
+		for path, test := range l.Imports(pkgs[n.ID], tests && n.Listed) {
+			if _, ok := pkgs[path]; ok && path != n.ID {
+				g.Edges = append(g.Edges, &GraphEdge{From: n.ID, To: path, Test: test})
+			}
+		}
+	}
+	g.sort()
+	return g
+}
+
+// Collapse returns a new graph where each node is replaced by the node returned from id.
+// Edges between nodes that collapse into the same node are dropped.
+func (g *Graph) Collapse(id func(n *GraphNode) string) *Graph {
+	c := &Graph{}
+	nodes := make(map[string]*GraphNode)
+	ids := make(map[string]string, len(g.Nodes))
+	for _, n := range g.Nodes {
+		cid := id(n)
+		ids[n.ID] = cid
+		cn, ok := nodes[cid]
+		if !ok {
+			cn = &GraphNode{ID: cid, Listed: n.Listed, Standard: n.Standard, Vendored: n.Vendored}
+			nodes[cid] = cn
+			c.Nodes = append(c.Nodes, cn)
+		}
+		cn.Listed = cn.Listed || n.Listed
+	}
+	edges := make(map[[2]string]*GraphEdge)
+	for _, e := range g.Edges {
+		key := [2]string{ids[e.From], ids[e.To]}
+		if key[0] == key[1] {
+			continue
+		}
+		if ce, ok := edges[key]; ok {
+			ce.Test = ce.Test && e.Test
+			continue
+		}
+		ce := &GraphEdge{From: key[0], To: key[1], Test: e.Test}
+		edges[key] = ce
+		c.Edges = append(c.Edges, ce)
+	}
+	c.sort()
+	return c
+}
+
+func (g *Graph) sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
@@ -1,10 +1,13 @@
-package main
+package gdl
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
-	"os/exec"
+	"fmt"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -26,10 +29,12 @@ type Package struct {
 	Shlib         string `json:",omitempty"` // the shared library that contains this package (only set when -linkshared)
 	Goroot        bool   `json:",omitempty"` // is this package found in the Go root?
 	Standard      bool   `json:",omitempty"` // is this package part of the standard Go library?
+	DepOnly       bool   `json:",omitempty"` // package is only a dependency, not explicitly listed
 	Stale         bool   `json:",omitempty"` // would 'go install' do anything for this package?
 	StaleReason   string `json:",omitempty"` // why is Stale true?
 	Root          string `json:",omitempty"` // Go root or Go path dir containing this package
 	ConflictDir   string `json:",omitempty"` // Dir is hidden by this other directory
+	ForTest       string `json:",omitempty"` // package is only for use in named test
 	BinaryOnly    bool   `json:",omitempty"` // package cannot be recompiled
 	// Source files
 	GoFiles        []string `json:",omitempty"` // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
@@ -63,6 +68,8 @@ type Package struct {
 	TestImports  []string `json:",omitempty"` // imports from TestGoFiles
 	XTestGoFiles []string `json:",omitempty"` // _test.go files outside package
 	XTestImports []string `json:",omitempty"` // imports from XTestGoFiles
+	// Module information
+	Module *Module `json:",omitempty"` // info about package's containing Module, if any
 
 	Vendored bool
 }
@@ -76,70 +83,131 @@ type PackageError struct {
 	hard          bool     // whether the error is soft or hard; soft errors are ignored in some places
 }
 
+func (p *PackageError) Error() string {
+	if p.Pos != "" {
+		return p.Pos + ": " + p.Err
+	}
+	return p.Err
+}
+
+// loadErrors returns an error with the full errors of every package that failed to load,
+// or nil if all packages loaded.
+func loadErrors(pkgs []*Package) error {
+	var b strings.Builder
+	failed := 0
+	for _, p := range pkgs {
+		if p.Error == nil && len(p.DepsErrors) == 0 && !p.Incomplete {
+			continue
+		}
+		failed++
+		fmt.Fprintf(&b, "\n%s:", p.ImportPath)
+		if p.Error == nil && len(p.DepsErrors) == 0 {
+			b.WriteString(" incomplete")
+		}
+		for _, err := range append([]*PackageError{p.Error}, p.DepsErrors...) {
+			if err == nil {
+				continue
+			}
+			b.WriteString("\n\t" + strings.Replace(err.Error(), "\n", "\n\t", -1))
+			if len(err.ImportStack) > 0 {
+				b.WriteString("\n\timport stack: " + strings.Join(err.ImportStack, " -> "))
+			}
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return errors.Errorf("%d packages failed to load:%s", failed, b.String())
+}
+
 // List names of packages from import paths.
-func listPackages(importPaths ...string) ([]string, error) {
+func listPackages(ctx context.Context, g goTool, importPaths ...string) ([]string, error) {
 	if len(importPaths) == 0 {
 		return nil, nil
 	}
-	args := append([]string{"list", "-e"}, importPaths...)
-	cmd := exec.Command("go", args...)
-	stdout, err := cmd.StdoutPipe()
+	out, err := g.list(ctx, append([]string{"-e"}, importPaths...)...)
 	if err != nil {
-		return nil, errors.Wrap(err, "initializing stdout for go list cmd")
-	}
-	if err := cmd.Start(); err != nil {
-		return nil, errors.Wrap(err, "starting go list cmd")
+		return nil, errors.Wrap(err, "go list cmd failed")
 	}
 	packages := make([]string, 0, len(importPaths))
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
 	for scanner.Scan() {
 		packages = append(packages, scanner.Text())
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, errors.Wrap(err, "uncountered err reading command output")
 	}
-	if err := cmd.Wait(); err != nil {
-		return nil, errors.Wrap(err, "go list cmd failed")
-	}
 	return packages, nil
 }
 
-// List information on a specific package or a wildcard match.
-func listPackageDetails(currentPath string, skipVendored bool, importPaths ...string) (map[string]*Package, error) {
-	if len(importPaths) == 0 {
-		return nil, nil
+// listDeps lists the packages matching the import paths along with all of their dependencies in a single pass.
+// The test variants and test binaries of the matched packages, and their dependencies, are included if tests is true.
+func listDeps(ctx context.Context, g goTool, tests bool, importPaths ...string) ([]*Package, error) {
+	args := []string{"-e", "-deps", "-json"}
+	if tests {
+		args = append(args, "-test")
 	}
-	vendoredPath := path.Join(currentPath, "vendor") + "/"
-	args := append([]string{"list", "-e", "-json"}, importPaths...)
-	cmd := exec.Command("go", args...)
-	stdout, err := cmd.StdoutPipe()
+	out, err := g.list(ctx, append(args, importPaths...)...)
 	if err != nil {
-		return nil, errors.Wrap(err, "initializing stdout for go list cmd")
-	}
-	if err := cmd.Start(); err != nil {
-		return nil, errors.Wrap(err, "starting go list cmd")
+		return nil, errors.Wrap(err, "go list cmd failed")
 	}
-	packages := make(map[string]*Package)
-	dec := json.NewDecoder(stdout)
+	var packages []*Package
+	dec := json.NewDecoder(bytes.NewReader(out))
 	for dec.More() {
 		p := &Package{}
 		if err := dec.Decode(p); err != nil {
 			return nil, errors.Wrap(err, "invalid json go list cmd")
 		}
-		// Rewrite vendored packages
-		if strings.HasPrefix(p.ImportPath, vendoredPath) {
-			if skipVendored {
-				continue
-			}
-			p.ImportPath = p.ImportPath[len(vendoredPath):]
-			p.Vendored = true
+		packages = append(packages, p)
+	}
+	return packages, nil
+}
+
+// trimVariant strips the test variant suffix from an import path, i.e. "foo [foo.test]" becomes "foo".
+func trimVariant(importPath string) string {
+	if i := strings.Index(importPath, " ["); i >= 0 {
+		return importPath[:i]
+	}
+	return importPath
+}
+
+// isTestOnly reports whether the package is a test binary or external test package generated by 'go list -test'.
+func isTestOnly(p *Package) bool {
+	if p.ForTest != "" {
+		return trimVariant(p.ImportPath) == p.ForTest+"_test"
+	}
+	return p.Name == "main" && strings.HasSuffix(p.ImportPath, ".test")
+}
+
+// currentImportPath derives the import path of the directory from the matched packages at or below it.
+// Reports false if none of the matched packages are in the directory.
+func currentImportPath(dir string, packages []*Package) (string, bool) {
+	for _, p := range packages {
+		if p.DepOnly || p.ForTest != "" || p.Dir == "" || isTestOnly(p) {
+			continue
+		}
+		rel, err := filepath.Rel(dir, p.Dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rel == "." {
+			return p.ImportPath, true
+		}
+		if suffix := "/" + filepath.ToSlash(rel); strings.HasSuffix(p.ImportPath, suffix) {
+			return strings.TrimSuffix(p.ImportPath, suffix), true
 		}
-		packages[p.ImportPath] = p
 	}
-	if err := cmd.Wait(); err != nil {
-		return nil, errors.Wrap(err, "go list cmd failed")
+	return "", false
+}
+
+// unvendor strips the vendor directory of the current package from the import path
+// and reports whether the import path was vendored.
+func unvendor(currentPath, importPath string) (string, bool) {
+	vendoredPath := path.Join(currentPath, "vendor") + "/"
+	if strings.HasPrefix(importPath, vendoredPath) {
+		return importPath[len(vendoredPath):], true
 	}
-	return packages, nil
+	return importPath, false
 }
 
 type Packages []*Package
@@ -148,86 +216,201 @@ func (p Packages) Len() int           { return len(p) }
 func (p Packages) Less(i, j int) bool { return p[i].ImportPath < p[j].ImportPath }
 func (p Packages) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
-func findDeps(standards, tests, skipVendored bool, importPaths ...string) (Packages, error) {
-	currentPackages, err := listPackages(".")
-	if err != nil {
-		return nil, errors.Wrap(err, "listing current package")
+// A Listing is the result of finding the dependencies of a set of packages.
+type Listing struct {
+	// Directory the packages were listed from
+	Dir string
+	// Import path of the package in the directory
+	Current string
+	// Packages matched by the import paths, excluding vendored packages
+	Packages Packages
+	// Dependencies of the packages sorted by import path
+	Deps Packages
+	// All loaded packages by import path
+	All map[string]*Package
+	// Import paths of the dependencies imported directly by the packages, or by their tests when listed,
+	// or by any other package below the current package
+	Direct map[string]bool
+	// Modules in the build list by module path, nil unless in module mode
+	Modules map[string]*Module
+	// Problems that did not stop the loading, i.e. a build list that could not be computed
+	Warnings []string
+}
+
+// Imports returns the import paths imported by pkg, with any vendored import paths rewritten.
+// Test imports are only returned if tests is true.
+// The returned map indicates whether each import only exists via test imports.
+func (l *Listing) Imports(pkg *Package, tests bool) map[string]bool {
+	imports := make(map[string]bool, len(pkg.Imports))
+	if tests {
+		for _, list := range [][]string{pkg.TestImports, pkg.XTestImports} {
+			for _, path := range list {
+				path, _ = unvendor(l.Current, path)
+				imports[path] = true
+			}
+		}
 	}
-	if len(currentPackages) != 1 {
-		return nil, errors.New("extra results getting current package")
+	for _, path := range pkg.Imports {
+		path, _ = unvendor(l.Current, path)
+		imports[path] = false
 	}
-	currentPackage := currentPackages[0]
-	packages, err := listPackageDetails(currentPackage, skipVendored, importPaths...)
+	return imports
+}
+
+func findDeps(ctx context.Context, g goTool, standards, tests, skipVendored bool, importPaths ...string) (*Listing, error) {
+	listed, err := listDeps(ctx, g, tests, importPaths...)
 	if err != nil {
 		return nil, errors.Wrap(err, "listing packages")
 	}
+	currentPackage, ok := currentImportPath(g.Dir, listed)
+	if !ok {
+		// None of the packages are in the current directory, ask for its import path
+		currentPackages, err := listPackages(ctx, g, ".")
+		if err != nil {
+			return nil, errors.Wrap(err, "listing current package")
+		}
+		if len(currentPackages) != 1 {
+			return nil, errors.New("extra results getting current package")
+		}
+		currentPackage = currentPackages[0]
+	}
+
+	vendorDir := filepath.Join(g.Dir, "vendor") + string(filepath.Separator)
+	packages := make(map[string]*Package, len(listed))
+	variants := make(map[string]bool)
+	// Matched packages whose dependencies are listed
+	var roots Packages
+	for _, p := range listed {
+		if isTestOnly(p) {
+			continue
+		}
+		variant := p.ForTest != ""
+		if variant {
+			// Packages recompiled for a test are only kept if they are not otherwise a dependency
+			if _, ok := packages[trimVariant(p.ImportPath)]; ok {
+				continue
+			}
+			p.ImportPath = trimVariant(p.ImportPath)
+			for i := range p.Imports {
+				p.Imports[i] = trimVariant(p.Imports[i])
+			}
+			for i := range p.Deps {
+				p.Deps[i] = trimVariant(p.Deps[i])
+			}
+		}
+		// Rewrite vendored packages
+		if importPath, ok := unvendor(currentPackage, p.ImportPath); ok {
+			p.ImportPath = importPath
+			p.Vendored = true
+		} else if p.Module != nil && strings.HasPrefix(p.Dir, vendorDir) {
+			// In module mode vendored packages keep their import path
+			p.Vendored = true
+		}
+		if _, ok := packages[p.ImportPath]; ok && !variants[p.ImportPath] {
+			continue
+		}
+		packages[p.ImportPath] = p
+		variants[p.ImportPath] = variant
+		if !variant && !p.DepOnly && !(skipVendored && p.Vendored) {
+			roots = append(roots, p)
+		}
+	}
+	matched := make(Packages, 0, len(roots))
+	for _, pkg := range roots {
+		if !pkg.Vendored {
+			matched = append(matched, pkg)
+		}
+	}
+	sort.Sort(matched)
 
 	// List of all deps
-	deps := make(Packages, 0, len(packages)*3)
+	deps := make(Packages, 0, len(packages))
 	// Keep track of included deps
-	included := make(map[string]bool, len(packages)*3)
+	included := make(map[string]bool, len(packages))
 	// Helper to add dep
-	addDep := func(dp *Package) {
+	addDep := func(path string) {
+		path, _ = unvendor(currentPackage, trimVariant(path))
+		dp, ok := packages[path]
+		if !ok {
+			return
+		}
 		if !included[dp.ImportPath] && (standards || !dp.Standard) && !strings.HasPrefix(dp.ImportPath, currentPackage) {
 			deps = append(deps, dp)
 		}
 		// Mark as included, even if not actually added because now we know it won't need to be added.
 		included[dp.ImportPath] = true
 	}
-	if tests {
-		testPackageSet := make(map[string]struct{})
-		for _, pkg := range packages {
-			for _, list := range [][]string{pkg.TestImports, pkg.XTestImports} {
-				for _, path := range list {
-					if _, ok := packages[path]; !ok {
-						testPackageSet[path] = struct{}{}
-					}
+	for _, pkg := range roots {
+		for _, dep := range pkg.Deps {
+			addDep(dep)
+		}
+		if !tests {
+			continue
+		}
+		for _, list := range [][]string{pkg.TestImports, pkg.XTestImports} {
+			for _, path := range list {
+				path, _ = unvendor(currentPackage, path)
+				tp, ok := packages[path]
+				if !ok || tp == pkg {
+					continue
+				}
+				addDep(path)
+				for _, dep := range tp.Deps {
+					addDep(dep)
 				}
 			}
 		}
-		testImports := make([]string, len(testPackageSet))
-		for path := range testPackageSet {
-			testImports = append(testImports, path)
-		}
-		testPackages, err := listPackageDetails(currentPackage, false, testImports...)
+	}
+	sort.Sort(deps)
+
+	// In module mode replace the module of each package with the build list entry,
+	// as 'go list' only reports whether a module is indirect when listing modules.
+	var modules map[string]*Module
+	var warnings []string
+	if ok, err := moduleMode(ctx, g); err != nil {
+		return nil, errors.Wrap(err, "detecting module mode")
+	} else if ok {
+		modules, warnings, err = buildList(ctx, g, packages)
 		if err != nil {
-			return nil, errors.Wrap(err, "listing test packages")
+			return nil, err
 		}
-		for path, pkg := range testPackages {
-			packages[path] = pkg
-			addDep(pkg)
+		for _, pkg := range packages {
+			if pkg.Module == nil {
+				continue
+			}
+			if m, ok := modules[pkg.Module.Path]; ok {
+				pkg.Module = m
+			}
 		}
 	}
-	// Set of deps that where not already listed
-	missingSet := make(map[string]struct{})
 
-	// Collect all deps
-	for _, pkg := range packages {
-		for _, dep := range pkg.Deps {
-			dp, ok := packages[dep]
-			if !ok {
-				missingSet[dep] = struct{}{}
-			} else {
-				addDep(dp)
+	l := &Listing{
+		Current:  currentPackage,
+		Packages: matched,
+		Deps:     deps,
+		All:      packages,
+		Direct:   make(map[string]bool),
+		Modules:  modules,
+		Warnings: warnings,
+	}
+	// Any package below the current package is our own code, listed or not,
+	// only the test imports of the listed packages are built.
+	for _, pkg := range matched {
+		for path := range l.Imports(pkg, tests) {
+			if included[path] {
+				l.Direct[path] = true
 			}
 		}
 	}
-
-	// List any dep packages there weren't already listed
-	if len(missingSet) > 0 {
-		paths := make([]string, len(missingSet))
-		for path := range missingSet {
-			paths = append(paths, path)
-		}
-		dps, err := listPackageDetails(currentPackage, false, paths...)
-		if err != nil {
-			return nil, errors.Wrap(err, "listing missing packages")
+	for _, pkg := range packages {
+		if !strings.HasPrefix(pkg.ImportPath, currentPackage) || pkg.Vendored {
+			continue
 		}
-		for _, dp := range dps {
-			addDep(dp)
+		for path := range l.Imports(pkg, false) {
+			if included[path] {
+				l.Direct[path] = true
+			}
 		}
 	}
-	sort.Sort(deps)
-
-	return deps, nil
+	return l, nil
 }
@@ -0,0 +1,64 @@
+// Package gdltest provides a fake go command and repo resolver for the tests of gdl and its commands,
+// replaying the recordings in the testdata directory of gdl.
+package gdltest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// Go replays the output of the go command recorded in testdata/go.
+// The recordings were made with GO111MODULE=off in testdata/gopath, trimmed to the fields gdl reads
+// and to the standard packages imported directly, with the GOPATH, GOROOT and GOCACHE directories replaced
+// by $GOPATH, $GOROOT and $GOCACHE.
+type Go struct {
+	Testdata string // path of the testdata directory
+	GOPATH   string // replaces $GOPATH in the recordings
+}
+
+// Recorded output file by the args of the go command.
+var outputs = map[string]string{
+	"env GOMOD":                                    "env_gomod.txt",
+	"list -e -deps -json .":                        "list_dot.json",
+	"list -e -deps -json ./...":                    "list.json",
+	"list -e -deps -json -test ./...":              "list_test.json",
+	"list -e -deps -json ./... ./vendor/...":       "list_vendor.json",
+	"list -e -deps -json -test ./... ./vendor/...": "list_vendor_test.json",
+}
+
+func (g Go) Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	name, ok := outputs[strings.Join(args, " ")]
+	if !ok {
+		return nil, fmt.Errorf("no recorded output for go %s", strings.Join(args, " "))
+	}
+	out, err := ioutil.ReadFile(filepath.Join(g.Testdata, "go", name))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Replace(out, []byte("$GOPATH"), []byte(g.GOPATH), -1), nil
+}
+
+// Resolver resolves repos from a fixed set of repos instead of the network.
+type Resolver map[string]*vcs.RepoRoot
+
+func (r Resolver) RepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	for root, repo := range r {
+		if importPath == root || strings.HasPrefix(importPath, root+"/") {
+			return repo, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown repo for %s", importPath)
+}
+
+// Repos are the repos of the packages in testdata/gopath.
+var Repos = Resolver{
+	"github.com/foo/bar":    {VCS: vcs.ByCmd("git"), Repo: "https://github.com/foo/bar", Root: "github.com/foo/bar"},
+	"github.com/foo/assert": {VCS: vcs.ByCmd("hg"), Repo: "https://github.com/foo/assert", Root: "github.com/foo/assert"},
+	"github.com/pkg/errors": {VCS: vcs.ByCmd("git"), Repo: "https://github.com/pkg/errors", Root: "github.com/pkg/errors"},
+}
@@ -0,0 +1,278 @@
+package gdl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Minimum confidence for a license file to be classified, by an excerpt or by a full license text.
+// A full license must match nearly exactly, as a single extra clause changes its terms.
+const (
+	licenseThreshold     = 0.75
+	licenseFullThreshold = 0.98
+)
+
+// A LicenseFile is a license, copying or notice file found for a dependency.
+type LicenseFile struct {
+	Path       string
+	ID         string  `json:",omitempty"` // SPDX identifier, empty if the file was not classified
+	Confidence float64 `json:",omitempty"` // similarity of the file to the reference license text
+}
+
+// isLicenseFile reports whether the file name is a license, copying or notice file.
+func isLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "NOTICE", "UNLICENSE"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// repoDir returns the directory of the repo root of the dependency,
+// or the dependency directory if it cannot be determined.
+func repoDir(d *Dependency) string {
+	if d.Standard {
+		return d.pkg.Root
+	}
+	rest := strings.TrimPrefix(d.ImportPath, d.Root)
+	dir := filepath.ToSlash(d.Dir)
+	if rest == d.ImportPath || !strings.HasSuffix(dir, rest) {
+		return d.Dir
+	}
+	return filepath.FromSlash(strings.TrimSuffix(dir, rest))
+}
+
+// A licenseFinder finds and classifies the license files of dependencies,
+// only classifying the files of each directory once.
+type licenseFinder struct {
+	refs  []licenseRef
+	found map[string][]*LicenseFile
+}
+
+type licenseRef struct {
+	ID     string
+	Full   bool
+	ngrams map[string]bool
+}
+
+func newLicenseFinder() *licenseFinder {
+	f := &licenseFinder{
+		refs:  make([]licenseRef, len(spdxLicenses)),
+		found: make(map[string][]*LicenseFile),
+	}
+	for i, l := range spdxLicenses {
+		f.refs[i] = licenseRef{ID: l.ID, Full: l.Full, ngrams: licenseNgrams(l.Text)}
+	}
+	return f
+}
+
+// Find returns the license files of the dependency from the nearest directory,
+// walking up from the dependency directory to its repo root, that contains any.
+func (f *licenseFinder) Find(d *Dependency) ([]*LicenseFile, error) {
+	if d.Dir == "" {
+		return nil, nil
+	}
+	root := repoDir(d)
+	for dir := d.Dir; ; dir = filepath.Dir(dir) {
+		files, err := f.findInDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 || dir == root || dir == filepath.Dir(dir) || !strings.HasPrefix(dir, root) {
+			return files, nil
+		}
+	}
+}
+
+func (f *licenseFinder) findInDir(dir string) ([]*LicenseFile, error) {
+	if files, ok := f.found[dir]; ok {
+		return files, nil
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading directory %s", dir)
+	}
+	var files []*LicenseFile
+	for _, info := range infos {
+		if info.IsDir() || !isLicenseFile(info.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, info.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading license file %s", path)
+		}
+		lf := &LicenseFile{Path: path}
+		lf.ID, lf.Confidence = f.classify(string(data))
+		files = append(files, lf)
+	}
+	f.found[dir] = files
+	return files, nil
+}
+
+// classify returns the license that best matches the text and the confidence of the match.
+// Returns an empty license if no license matches with enough confidence.
+//
+// A full license text is scored by the Dice coefficient of the trigrams of both texts,
+// so any text added to or missing from the license lowers the score.
+// An excerpt is scored by the fraction of its trigrams found in the text,
+// as the rest of the text is the rest of the license.
+// Only the scores of the licenses that reach their own threshold are compared.
+func (f *licenseFinder) classify(text string) (string, float64) {
+	ngrams := licenseNgrams(text)
+	bestID, bestScore, maxScore := "", 0.0, 0.0
+	for _, ref := range f.refs {
+		matched := 0
+		for ngram := range ref.ngrams {
+			if ngrams[ngram] {
+				matched++
+			}
+		}
+		score, threshold := float64(matched)/float64(len(ref.ngrams)), licenseThreshold
+		if ref.Full {
+			score, threshold = 2*float64(matched)/float64(len(ref.ngrams)+len(ngrams)), licenseFullThreshold
+		}
+		if score > maxScore {
+			maxScore = score
+		}
+		if score >= threshold && score > bestScore {
+			bestID, bestScore = ref.ID, score
+		}
+	}
+	if bestID == "" {
+		return "", maxScore
+	}
+	return bestID, bestScore
+}
+
+// listMarker matches the number or bullet of a list item at the start of a line.
+var listMarker = regexp.MustCompile(`^(\(?([0-9]+|[a-z])[.)]|[*\-\x{2022}])\s+`)
+
+// licenseNgrams returns the set of word trigrams in the normalized text.
+// Copyright and "All rights reserved" lines, list markers and the name of the copyright holder
+// in the "Neither the name of ... nor" clause are ignored, as they differ for every copy of a license.
+func licenseNgrams(text string) map[string]bool {
+	var words []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "copyright") || strings.HasPrefix(line, "(c)") || strings.HasPrefix(line, "all rights reserved") {
+			continue
+		}
+		line = listMarker.ReplaceAllString(line, "")
+		words = append(words, strings.FieldsFunc(line, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	words = normalizeHolder(words)
+	ngrams := make(map[string]bool, len(words))
+	for i := 0; i+3 <= len(words); i++ {
+		ngrams[strings.Join(words[i:i+3], " ")] = true
+	}
+	return ngrams
+}
+
+// normalizeHolder replaces the copyright holder named in the words by "the copyright holder",
+// in "neither the name of HOLDER nor" and in "copyright owner".
+func normalizeHolder(words []string) []string {
+	holder := []string{"the", "copyright", "holder"}
+	var out []string
+	for i := 0; i < len(words); i++ {
+		if i+1 < len(words) && words[i] == "copyright" && words[i+1] == "owner" {
+			out = append(out, "copyright", "holder")
+			i++
+			continue
+		}
+		out = append(out, words[i])
+		if i+4 > len(words) || strings.Join(words[i:i+4], " ") != "neither the name of" {
+			continue
+		}
+		// The name is at most a few words long
+		for j := i + 4; j < len(words) && j < i+16; j++ {
+			if words[j] == "nor" {
+				out = append(append(out, words[i+1:i+4]...), holder...)
+				i = j - 1
+				break
+			}
+		}
+	}
+	return out
+}
+
+// licenseIDs returns the sorted unique identifiers of the classified license files.
+func licenseIDs(files []*LicenseFile) []string {
+	set := make(map[string]bool, len(files))
+	var ids []string
+	for _, lf := range files {
+		if lf.ID != "" && !set[lf.ID] {
+			set[lf.ID] = true
+			ids = append(ids, lf.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// A LicenseSummary is the licenses of all dependencies of a single repo.
+type LicenseSummary struct {
+	Root     string
+	Repo     string
+	Revision string `json:",omitempty"` // revision, or version, of the repo
+	License  string
+	Packages []string
+	Files    []*LicenseFile
+}
+
+// SummarizeLicenses groups the licenses of the dependencies by repo.
+// The license files must have been found by listing with the Licenses option.
+func SummarizeLicenses(deps []*Dependency) []*LicenseSummary {
+	var summaries []*LicenseSummary
+	byRoot := make(map[string]*LicenseSummary)
+	files := make(map[string]map[string]bool)
+	for _, d := range deps {
+		s, ok := byRoot[d.Root]
+		if !ok {
+			s = &LicenseSummary{Root: d.Root, Repo: d.Repo, Revision: d.Revision}
+			if s.Revision == "" {
+				s.Revision = d.Version
+			}
+			if s.Repo == "" {
+				s.Repo = d.Source
+			}
+			byRoot[d.Root] = s
+			files[d.Root] = make(map[string]bool)
+			summaries = append(summaries, s)
+		}
+		s.Packages = append(s.Packages, d.ImportPath)
+		for _, lf := range d.LicenseFiles {
+			if !files[d.Root][lf.Path] {
+				files[d.Root][lf.Path] = true
+				s.Files = append(s.Files, lf)
+			}
+		}
+	}
+	for _, s := range summaries {
+		s.License = licenseName(licenseIDs(s.Files), len(s.Files))
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Root < summaries[j].Root })
+	return summaries
+}
+
+// licenseName returns the display name of the licenses from n license files.
+func licenseName(ids []string, n int) string {
+	switch {
+	case len(ids) > 0:
+		return strings.Join(ids, ", ")
+	case n > 0:
+		return "Unknown"
+	default:
+		return "None"
+	}
+}
@@ -0,0 +1,71 @@
+package gdl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// spdxText returns the first reference text of the license.
+func spdxText(id string) string {
+	for _, l := range spdxLicenses {
+		if l.ID == id {
+			return l.Text
+		}
+	}
+	panic("unknown license " + id)
+}
+
+func TestClassify(t *testing.T) {
+	mit := spdxText("MIT")
+	testCases := []struct {
+		name string
+		text string
+		id   string
+	}{
+		{name: "mit", text: "MIT License\n\nCopyright (c) 2018 The Authors\n" + mit, id: "MIT"},
+		{
+			name: "modified mit",
+			text: "Copyright (c) 2002 JSON.org\n" + strings.Replace(mit,
+				"The above copyright notice",
+				"The Software shall be used for Good, not Evil.\n\nThe above copyright notice", 1),
+		},
+		{name: "bsd-2", text: "Copyright (c) 2018 The Authors\n" + spdxText("BSD-2-Clause"), id: "BSD-2-Clause"},
+		{name: "bsd-3", text: "Copyright (c) 2018 The Authors\n" + spdxText("BSD-3-Clause"), id: "BSD-3-Clause"},
+		{name: "combined", text: mit + "\n\n" + spdxText("BSD-3-Clause")},
+		{name: "apache", text: spdxText("Apache-2.0") + "\n2. Grant of Copyright License. Subject to the terms...", id: "Apache-2.0"},
+		{name: "unrelated", text: "All rights reserved. Do not copy."},
+	}
+	f := newLicenseFinder()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, confidence := f.classify(tc.text)
+			if id != tc.id {
+				t.Errorf("got license %q with confidence %.2f, want %q", id, confidence, tc.id)
+			}
+			if tc.id == "" && confidence >= 1 {
+				t.Errorf("unexpected confidence %.2f for an unclassified license", confidence)
+			}
+		})
+	}
+}
+
+// TestClassifyFiles classifies real license files, with list markers,
+// "All rights reserved" lines and named copyright holders.
+func TestClassifyFiles(t *testing.T) {
+	f := newLicenseFinder()
+	for name, want := range map[string]string{
+		"go.txt":         "BSD-3-Clause",
+		"x-tools.txt":    "BSD-3-Clause",
+		"pkg-errors.txt": "BSD-2-Clause",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "licenses", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, confidence := f.classify(string(data)); id != want {
+			t.Errorf("%s: got license %q with confidence %.2f, want %q", name, id, confidence, want)
+		}
+	}
+}
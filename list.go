@@ -0,0 +1,211 @@
+package gdl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Options configure how dependencies are listed.
+// The zero value lists the dependencies of the current package, excluding the standard library,
+// resolving every repo over the network.
+type Options struct {
+	// Import paths, or patterns, of the packages to list, defaults to the current package
+	ImportPaths []string
+	// Directory to list the packages from, defaults to the current directory
+	Dir string
+	// Additional environment variables for the go command, i.e. GOPATH=/tmp/go
+	Env []string
+
+	Standard     bool     // include dependencies from the standard library
+	Tests        bool     // include dependencies from test files
+	SkipVendored bool     // skip packages vendored below the current package
+	Platforms    []string // GOOS/GOARCH platforms to list the union of the dependencies for
+	Tags         string   // comma separated build tags
+	Strict       bool     // fail if any package or dependency could not be loaded
+	Cgo          bool     // list the cgo files even without a C compiler, unless CGO_ENABLED is set
+
+	RootOnly   bool // only include the first dependency per repo
+	DirectOnly bool // only include the dependencies imported directly by the packages, or any package below the current package
+	Checkouts  bool // include the state of local checkouts
+	Licenses   bool // include the license files
+
+	Offline     bool          // never use the network to resolve repos, only the cache and well known hosts
+	CachePath   string        // path of the repo cache file, empty disables the on disk cache
+	CacheTTL    time.Duration // duration cached repos are used before they are resolved again, zero always resolves again
+	Concurrency int           // maximum number of repos to resolve at once, defaults to 1
+
+	Runner   Runner   // runs the go command, defaults to the go binary in PATH
+	Resolver Resolver // resolves the repos of import paths, defaults to the rules of 'go get'
+}
+
+// A Result is the dependencies found by List.
+type Result struct {
+	Listing      *Listing      // packages and dependencies as listed by 'go list'
+	Dependencies []*Dependency // dependencies with their repos and the requested details
+	Warnings     []string      // problems that did not stop the listing, i.e. unreadable checkouts
+}
+
+// goTool returns the go tool for the directory of the options.
+func (o Options) goTool() (goTool, error) {
+	g := goTool{Runner: o.Runner, Dir: o.Dir, Env: o.Env, Tags: o.Tags, Cgo: o.Cgo}
+	if g.Runner == nil {
+		g.Runner = execRunner{}
+	}
+	if g.Dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return g, errors.Wrap(err, "getting working directory")
+		}
+		g.Dir = wd
+	}
+	return g, nil
+}
+
+func (o Options) resolver() Resolver {
+	if o.Resolver != nil {
+		return o.Resolver
+	}
+	return vcsResolver{}
+}
+
+func (o Options) importPaths() []string {
+	if len(o.ImportPaths) > 0 {
+		return o.ImportPaths
+	}
+	return []string{"."}
+}
+
+// Load finds the packages and their dependencies, without resolving their repos.
+func Load(ctx context.Context, opts Options) (*Listing, error) {
+	listing, _, err := load(ctx, opts)
+	return listing, err
+}
+
+// load finds the packages and their dependencies,
+// returning the platforms that need each dependency when listing for several platforms.
+func load(ctx context.Context, opts Options) (*Listing, map[string][]string, error) {
+	g, err := opts.goTool()
+	if err != nil {
+		return nil, nil, err
+	}
+	targets, err := g.targets(opts.Platforms)
+	if err != nil {
+		return nil, nil, err
+	}
+	listing, platforms, err := findTargetDeps(ctx, targets, opts.Standard, opts.Tests, opts.SkipVendored, opts.importPaths()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Strict {
+		// The listed packages report the position and import stack of missing dependencies
+		if err := loadErrors(append(append([]*Package{}, listing.Packages...), listing.Deps...)); err != nil {
+			return nil, nil, err
+		}
+	}
+	listing.Dir = g.Dir
+	return listing, platforms, nil
+}
+
+// List finds the dependencies of the packages, resolves their repos
+// and includes any vendored project details along with the requested details.
+func List(ctx context.Context, opts Options) (Result, error) {
+	listing, platforms, err := load(ctx, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	warnings := append([]string{}, listing.Warnings...)
+	if opts.Cgo && cgoDisabled(opts.Env) {
+		warnings = append(warnings, "cgo is disabled by CGO_ENABLED=0, cgo files and their imports are not listed")
+	}
+	deps := listing.Deps
+	if opts.DirectOnly {
+		deps = make(Packages, 0, len(listing.Direct))
+		for _, dep := range listing.Deps {
+			if listing.Direct[dep.ImportPath] {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	cache, cacheWarnings, err := openRepoCache(opts.CachePath, opts.CacheTTL, opts.Offline)
+	if err != nil {
+		return Result{}, err
+	}
+	warnings = append(warnings, cacheWarnings...)
+	repos, err := findRepos(ctx, deps, cache, opts.resolver(), opts.Concurrency)
+	if err != nil {
+		return Result{}, err
+	}
+
+	dependencies := make([]*Dependency, 0, len(deps))
+	roots := make(map[string]bool, len(repos))
+	for i := range deps {
+		if opts.RootOnly && deps[i].ImportPath != repos[i].Root && roots[repos[i].Root] && !deps[i].Standard {
+			continue
+		}
+		roots[repos[i].Root] = true
+		d := newDependency(deps[i], repos[i])
+		d.Platforms = platforms[d.ImportPath]
+		d.Direct = listing.Direct[d.ImportPath]
+		dependencies = append(dependencies, d)
+	}
+
+	manifest, err := readVendorManifest(listing.Dir)
+	if err != nil {
+		return Result{}, err
+	}
+	if manifest != nil {
+		for _, d := range dependencies {
+			if !d.Vendored {
+				continue
+			}
+			if p := manifest.Lookup(d.ImportPath); p != nil {
+				d.setVendoredProject(manifest.Tool, p)
+			}
+		}
+	}
+
+	if opts.Checkouts {
+		checkouts := make(map[string]*Checkout)
+		for _, d := range dependencies {
+			// Only dependencies in GOPATH are in a checkout of their own
+			if d.Standard || d.Vendored || d.Module != "" {
+				continue
+			}
+			c, ok := checkouts[d.Root]
+			if !ok {
+				c, err = readCheckout(d.repo.VCS.Cmd, d.Dir)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("could not read checkout of %s: %v", d.Root, err))
+				}
+				checkouts[d.Root] = c
+			}
+			if c != nil {
+				d.setCheckout(c)
+			}
+		}
+	}
+
+	if opts.Licenses {
+		if err := findLicenses(dependencies); err != nil {
+			return Result{}, err
+		}
+	}
+	return Result{Listing: listing, Dependencies: dependencies, Warnings: warnings}, nil
+}
+
+// findLicenses sets the license files of the dependencies.
+func findLicenses(deps []*Dependency) error {
+	finder := newLicenseFinder()
+	for _, d := range deps {
+		files, err := finder.Find(d)
+		if err != nil {
+			return err
+		}
+		d.setLicenseFiles(files)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package gdl_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nathanielc/gdl"
+	"github.com/nathanielc/gdl/internal/gdltest"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata/golden.")
+
+// golden is the output compared against the golden files.
+type golden struct {
+	Packages     []string
+	Dependencies []*gdl.Dependency
+}
+
+func TestList(t *testing.T) {
+	gopath, err := filepath.Abs(filepath.Join("testdata", "gopath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name string
+		opts gdl.Options
+	}{
+		{name: "default"},
+		{name: "std", opts: gdl.Options{Standard: true}},
+		{name: "test", opts: gdl.Options{Tests: true}},
+		{name: "std_test", opts: gdl.Options{Standard: true, Tests: true}},
+		{name: "repo", opts: gdl.Options{RootOnly: true}},
+		{name: "repo_test", opts: gdl.Options{RootOnly: true, Tests: true}},
+		{name: "vendor", opts: gdl.Options{ImportPaths: []string{"./...", "./vendor/..."}, Standard: true}},
+		{name: "no_vendored", opts: gdl.Options{ImportPaths: []string{"./...", "./vendor/..."}, Standard: true, SkipVendored: true}},
+		{name: "no_vendored_test", opts: gdl.Options{ImportPaths: []string{"./...", "./vendor/..."}, SkipVendored: true, Tests: true}},
+		{name: "all", opts: gdl.Options{ImportPaths: []string{"./...", "./vendor/..."}, Standard: true, Tests: true, RootOnly: true, SkipVendored: true}},
+		{name: "direct", opts: gdl.Options{DirectOnly: true}},
+		{name: "direct_std_test", opts: gdl.Options{DirectOnly: true, Standard: true, Tests: true}},
+		{name: "licenses", opts: gdl.Options{Licenses: true}},
+		// strings is only imported by the unlisted example.com/app/internal/util
+		{name: "current_std", opts: gdl.Options{ImportPaths: []string{"."}, Standard: true}},
+		{name: "current_direct_std", opts: gdl.Options{ImportPaths: []string{"."}, Standard: true, DirectOnly: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
+			if opts.ImportPaths == nil {
+				opts.ImportPaths = []string{"./..."}
+			}
+			opts.Dir = filepath.Join(gopath, "src", "example.com", "app")
+			opts.Runner = gdltest.Go{Testdata: "testdata", GOPATH: gopath}
+			opts.Resolver = gdltest.Repos
+			opts.Concurrency = 4
+			res, err := gdl.List(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := golden{Dependencies: res.Dependencies}
+			for _, pkg := range res.Listing.Packages {
+				g.Packages = append(g.Packages, pkg.ImportPath)
+			}
+			got, err := json.MarshalIndent(g, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(bytes.Replace(got, []byte(gopath), []byte("$GOPATH"), -1), '\n')
+
+			path := filepath.Join("testdata", "golden", tc.name+".json")
+			if *update {
+				if err := ioutil.WriteFile(path, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("unexpected output, run with -update to update the golden file:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestListUnknownCommand(t *testing.T) {
+	_, err := gdl.List(context.Background(), gdl.Options{
+		ImportPaths: []string{"./cmd/..."},
+		Dir:         filepath.Join("testdata", "gopath", "src", "example.com", "app"),
+		Runner:      gdltest.Go{Testdata: "testdata"},
+		Resolver:    gdltest.Repos,
+	})
+	if err == nil || !strings.Contains(err.Error(), "no recorded output for go list -e -deps -json ./cmd/...") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package gdl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Lock records the revisions of the repos the packages depend on,
+// and the hashes of the vendored packages.
+type Lock struct {
+	Repos  []*LockedRepo
+	Vendor []*LockedPackage `json:",omitempty"`
+	// Problems that did not stop locking, i.e. uncommitted changes, not written to the lock file
+	Warnings []string `json:"-"`
+}
+
+// A LockedRepo is a single repo recorded in a lock.
+type LockedRepo struct {
+	Root     string // root import path of the repo
+	VCS      string // vcs command, i.e. git
+	Repo     string // repo url
+	Revision string // revision of the local checkout
+}
+
+// A LockedPackage is a single vendored package recorded in a lock.
+type LockedPackage struct {
+	ImportPath string
+	Dir        string // directory relative to the current directory
+	Hash       string // hash of the files in the directory, see hashPackageDir
+}
+
+// ReadLock reads the lock file at path.
+func ReadLock(path string) (*Lock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading lock file")
+	}
+	lock := &Lock{}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, errors.Wrapf(err, "invalid lock file %s", path)
+	}
+	return lock, nil
+}
+
+// Write writes the lock to the file at path.
+func (l *Lock) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "encoding lock file")
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "writing lock file")
+	}
+	return nil
+}
+
+// lockRepos returns the locked repos of the dependencies that are checked out in GOPATH,
+// and a warning for each checkout with uncommitted changes.
+func lockRepos(deps []*Dependency) ([]*LockedRepo, []string, error) {
+	var repos []*LockedRepo
+	var warnings []string
+	locked := make(map[string]bool)
+	for _, d := range deps {
+		// Vendored and module dependencies are already locked by other means
+		if d.Standard || d.Vendored || d.Module != "" || locked[d.Root] {
+			continue
+		}
+		locked[d.Root] = true
+		if d.Revision == "" {
+			return nil, nil, errors.Errorf("no local revision of %s found", d.Root)
+		}
+		if d.Dirty != nil && *d.Dirty {
+			warnings = append(warnings, fmt.Sprintf("checkout of %s has uncommitted changes, only the revision is locked", d.Root))
+		}
+		repos = append(repos, &LockedRepo{
+			Root:     d.Root,
+			VCS:      d.repo.VCS.Cmd,
+			Repo:     d.Repo,
+			Revision: d.Revision,
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Root < repos[j].Root })
+	return repos, warnings, nil
+}
+
+// lockVendor returns the locked packages of the vendored dependencies, relative to dir.
+func lockVendor(dir string, deps []*Dependency) ([]*LockedPackage, error) {
+	var pkgs []*LockedPackage
+	for _, d := range deps {
+		if !d.Vendored || d.Dir == "" {
+			continue
+		}
+		p, err := lockPackage(dir, d.ImportPath, d.Dir)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	return pkgs, nil
+}
+
+func lockPackage(base, importPath, dir string) (*LockedPackage, error) {
+	hash, err := hashPackageDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(base, dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding directory of %s", importPath)
+	}
+	return &LockedPackage{ImportPath: importPath, Dir: filepath.ToSlash(rel), Hash: hash}, nil
+}
+
+// NewLock locks the repos in GOPATH and the vendored packages the packages depend on.
+func NewLock(ctx context.Context, opts Options) (*Lock, error) {
+	opts.Checkouts = true
+	res, err := List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	repos, warnings, err := lockRepos(res.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+	vendor, err := lockVendor(res.Listing.Dir, res.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{Repos: repos, Vendor: vendor, Warnings: append(res.Warnings, warnings...)}, nil
+}
+
+// Functions to restore the checkout in dir of the repo at the revision, by VCS command.
+// The checkout is created if dir does not exist.
+var restorers = map[string]func(dir, repo, rev string) error{
+	"git": func(dir, repo, rev string) error {
+		if err := cloneIfMissing(dir, "git", "clone", "--quiet", repo, dir); err != nil {
+			return err
+		}
+		if _, err := vcsOutput(dir, "git", "cat-file", "-e", rev+"^{commit}"); err != nil {
+			if _, err := vcsOutput(dir, "git", "fetch", "--quiet", "origin"); err != nil {
+				return err
+			}
+		}
+		_, err := vcsOutput(dir, "git", "checkout", "--quiet", rev)
+		return err
+	},
+	"hg": func(dir, repo, rev string) error {
+		if err := cloneIfMissing(dir, "hg", "clone", "--quiet", "--noupdate", repo, dir); err != nil {
+			return err
+		}
+		if _, err := vcsOutput(dir, "hg", "log", "--quiet", "-r", rev); err != nil {
+			if _, err := vcsOutput(dir, "hg", "pull", "--quiet"); err != nil {
+				return err
+			}
+		}
+		_, err := vcsOutput(dir, "hg", "update", "--quiet", "-r", rev)
+		return err
+	},
+	"bzr": func(dir, repo, rev string) error {
+		if err := cloneIfMissing(dir, "bzr", "branch", "--quiet", repo, dir); err != nil {
+			return err
+		}
+		if _, err := vcsOutput(dir, "bzr", "pull", "--quiet", "--overwrite", "-r", "revid:"+rev); err != nil {
+			return err
+		}
+		_, err := vcsOutput(dir, "bzr", "update", "--quiet", "-r", "revid:"+rev)
+		return err
+	},
+	"svn": func(dir, repo, rev string) error {
+		if err := cloneIfMissing(dir, "svn", "checkout", "--quiet", "-r", rev, repo, dir); err != nil {
+			return err
+		}
+		_, err := vcsOutput(dir, "svn", "update", "--quiet", "-r", rev)
+		return err
+	},
+}
+
+// cloneIfMissing runs the clone command if dir does not exist.
+func cloneIfMissing(dir, name string, args ...string) error {
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return errors.Wrap(err, "creating repo parent directory")
+	}
+	_, err := vcsOutput(filepath.Dir(dir), name, args...)
+	return err
+}
+
+// restoreRepo restores the checkout of the repo below the src directory.
+func restoreRepo(src string, r *LockedRepo) error {
+	restore, ok := restorers[r.VCS]
+	if !ok {
+		return errors.Errorf("cannot restore %s, unsupported VCS %q", r.Root, r.VCS)
+	}
+	dir := filepath.Join(src, filepath.FromSlash(r.Root))
+	if _, err := os.Stat(dir); err == nil {
+		c, err := readCheckout(r.VCS, dir)
+		if err != nil {
+			return errors.Wrapf(err, "reading checkout of %s", r.Root)
+		}
+		if c.Revision == r.Revision {
+			return nil
+		}
+		if c.Dirty {
+			return errors.Errorf("cannot restore %s, checkout has uncommitted changes", r.Root)
+		}
+	}
+	if err := restore(dir, r.Repo, r.Revision); err != nil {
+		return errors.Wrapf(err, "restoring %s", r.Root)
+	}
+	return nil
+}
+
+// Restore restores the repos of the lock into the first GOPATH entry, as 'go get' does,
+// cloning any missing repos and checking out the locked revisions.
+func Restore(ctx context.Context, opts Options, lock *Lock) error {
+	g, err := opts.goTool()
+	if err != nil {
+		return err
+	}
+	out, err := g.run(ctx, "env", "GOPATH")
+	if err != nil {
+		return errors.Wrap(err, "go env cmd failed")
+	}
+	gopath := filepath.SplitList(strings.TrimSpace(string(out)))
+	if len(gopath) == 0 {
+		return errors.New("no GOPATH set")
+	}
+	src := filepath.Join(gopath[0], "src")
+	for _, r := range lock.Repos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := restoreRepo(src, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
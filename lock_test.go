@@ -0,0 +1,118 @@
+package gdl
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// gopathRunner answers 'go env GOPATH' with a fixed GOPATH.
+type gopathRunner string
+
+func (r gopathRunner) Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	return []byte(string(r) + "\n"), nil
+}
+
+// git runs git in dir with a fixed identity and returns its trimmed output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=gdl", "-c", "user.email=gdl@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes the file in the repo and commits it, returning the new revision.
+func commit(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", name)
+	git(t, dir, "commit", "--quiet", "-m", "update "+name)
+	return git(t, dir, "rev-parse", "HEAD")
+}
+
+func TestLockRestoreGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	tmp := t.TempDir()
+	origin := filepath.Join(tmp, "origin")
+	git(t, tmp, "init", "--quiet", origin)
+	first := commit(t, origin, "a.go", "package a\n")
+	second := commit(t, origin, "a.go", "package a // changed\n")
+
+	gopath := filepath.Join(tmp, "gopath")
+	dir := filepath.Join(gopath, "src", "example.com", "a")
+	opts := Options{Runner: gopathRunner(gopath)}
+	lock := &Lock{Repos: []*LockedRepo{{Root: "example.com/a", VCS: "git", Repo: "file://" + origin, Revision: first}}}
+	revision := func() string {
+		t.Helper()
+		c, err := readCheckout("git", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Revision
+	}
+
+	// Restoring a missing repo clones it at the locked revision
+	if err := Restore(context.Background(), opts, lock); err != nil {
+		t.Fatal(err)
+	}
+	if got := revision(); got != first {
+		t.Fatalf("restored revision %s, want %s", got, first)
+	}
+
+	// Locking the checkout records its revision
+	c, err := readCheckout("git", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dependency{ImportPath: "example.com/a", Root: "example.com/a", Repo: "file://" + origin, repo: &vcs.RepoRoot{VCS: vcs.ByCmd("git")}}
+	d.setCheckout(c)
+	repos, warnings, err := lockRepos([]*Dependency{d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || *repos[0] != *lock.Repos[0] || len(warnings) != 0 {
+		t.Fatalf("unexpected locked repos %+v, warnings %v", repos, warnings)
+	}
+
+	// Restoring a moved checkout returns it to the locked revision
+	git(t, dir, "checkout", "--quiet", second)
+	if err := Restore(context.Background(), opts, lock); err != nil {
+		t.Fatal(err)
+	}
+	if got := revision(); got != first {
+		t.Fatalf("restored revision %s, want %s", got, first)
+	}
+
+	// Uncommitted changes are warned about when locking, and never overwritten when restoring
+	git(t, dir, "checkout", "--quiet", second)
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte("package a // local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = readCheckout("git", dir); err != nil {
+		t.Fatal(err)
+	}
+	d.setCheckout(c)
+	if _, warnings, err = lockRepos([]*Dependency{d}); err != nil || len(warnings) != 1 {
+		t.Errorf("expected a warning for uncommitted changes, got %v, %v", warnings, err)
+	}
+	err = Restore(context.Background(), opts, lock)
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("expected an error for uncommitted changes, got %v", err)
+	}
+	if got := revision(); got != second {
+		t.Errorf("checkout with uncommitted changes moved to %s", got)
+	}
+}
@@ -0,0 +1,312 @@
+package gdl
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A VendoredProject is a single project recorded in a vendor manifest.
+type VendoredProject struct {
+	Root     string // import path of the project, or of a package within the project
+	Revision string // vendored revision
+	Version  string // vendored version, tag or branch
+	Source   string // alternate location the project was vendored from
+}
+
+// A VendorManifest records the projects vendored by a vendoring tool.
+type VendorManifest struct {
+	Tool     string // name of the tool managing the vendor tree
+	File     string // path of the manifest
+	Projects []*VendoredProject
+}
+
+// Lookup returns the project with the longest root that contains the import path.
+func (m *VendorManifest) Lookup(importPath string) *VendoredProject {
+	var found *VendoredProject
+	for _, p := range m.Projects {
+		if (importPath == p.Root || strings.HasPrefix(importPath, p.Root+"/")) &&
+			(found == nil || len(p.Root) > len(found.Root)) {
+			found = p
+		}
+	}
+	return found
+}
+
+// Known vendor manifests in order of preference.
+var vendorManifests = []struct {
+	Tool  string
+	File  string
+	Parse func(r io.Reader) ([]*VendoredProject, error)
+}{
+	{"modules", "vendor/modules.txt", parseModulesTxt},
+	{"dep", "Gopkg.lock", parseGopkgLock},
+	{"glide", "glide.lock", parseGlideLock},
+	{"govendor", "vendor/vendor.json", parseGovendor},
+	{"godep", "Godeps/Godeps.json", parseGodeps},
+}
+
+// readVendorManifest reads the vendor manifest from dir.
+// Returns nil if no known manifest exists.
+func readVendorManifest(dir string) (*VendorManifest, error) {
+	for _, vm := range vendorManifests {
+		file := filepath.Join(dir, filepath.FromSlash(vm.File))
+		f, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "opening %s", file)
+		}
+		projects, err := vm.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", file)
+		}
+		return &VendorManifest{Tool: vm.Tool, File: file, Projects: projects}, nil
+	}
+	projects, err := findSubrepos(filepath.Join(dir, "vendor"))
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) > 0 {
+		return &VendorManifest{Tool: "git-subrepo", File: filepath.Join(dir, "vendor"), Projects: projects}, nil
+	}
+	return nil, nil
+}
+
+var pseudoVersion = regexp.MustCompile(`-(?:0\.)?\d{14}-([0-9a-f]{12})(?:\+incompatible)?$`)
+
+// parseModulesTxt parses a vendor/modules.txt file written by 'go mod vendor'.
+//
+//	# github.com/pkg/errors v0.8.1
+//	# golang.org/x/tools v0.1.0 => ../tools
+func parseModulesTxt(r io.Reader) ([]*VendoredProject, error) {
+	var projects []*VendoredProject
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		fields := strings.Fields(line[2:])
+		if len(fields) < 2 {
+			continue
+		}
+		p := &VendoredProject{Root: fields[0], Version: fields[1]}
+		if len(fields) >= 4 && fields[2] == "=>" {
+			p.Source = strings.Join(fields[3:], " ")
+		}
+		if m := pseudoVersion.FindStringSubmatch(p.Version); m != nil {
+			p.Revision = m[1]
+		}
+		projects = append(projects, p)
+	}
+	return projects, scanner.Err()
+}
+
+// parseGopkgLock parses the projects of a Gopkg.lock file written by dep.
+//
+//	[[projects]]
+//	  name = "github.com/pkg/errors"
+//	  revision = "645ef00459ed84a119197bfb8d8205042c6df63d"
+//	  version = "v0.8.0"
+func parseGopkgLock(r io.Reader) ([]*VendoredProject, error) {
+	var projects []*VendoredProject
+	var p *VendoredProject
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			p = nil
+			if line == "[[projects]]" {
+				p = &VendoredProject{}
+				projects = append(projects, p)
+			}
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+			continue
+		}
+		value = value[1 : len(value)-1]
+		switch strings.TrimSpace(line[:i]) {
+		case "name":
+			p.Root = value
+		case "revision":
+			p.Revision = value
+		case "version":
+			p.Version = value
+		case "branch":
+			if p.Version == "" {
+				p.Version = value
+			}
+		case "source":
+			p.Source = value
+		}
+	}
+	return projects, scanner.Err()
+}
+
+// parseGlideLock parses the imports of a glide.lock file.
+//
+//	imports:
+//	- name: github.com/pkg/errors
+//	  version: 645ef00459ed84a119197bfb8d8205042c6df63d
+//	  repo: https://github.com/pkg/errors
+func parseGlideLock(r io.Reader) ([]*VendoredProject, error) {
+	var projects []*VendoredProject
+	var p *VendoredProject
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] != ' ' && line[0] != '-' {
+			// A top level key ends the current list.
+			p = nil
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- name:") {
+			p = &VendoredProject{Root: yamlValue(line[len("- name:"):])}
+			projects = append(projects, p)
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		value := yamlValue(line[i+1:])
+		switch line[:i] {
+		case "version":
+			// glide locks the version to the exact revision
+			p.Revision = value
+		case "repo":
+			p.Source = value
+		}
+	}
+	return projects, scanner.Err()
+}
+
+func yamlValue(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
+
+// parseGovendor parses a vendor/vendor.json file written by govendor.
+func parseGovendor(r io.Reader) ([]*VendoredProject, error) {
+	var manifest struct {
+		Package []struct {
+			Path         string `json:"path"`
+			Origin       string `json:"origin"`
+			Revision     string `json:"revision"`
+			Version      string `json:"version"`
+			VersionExact string `json:"versionExact"`
+		} `json:"package"`
+	}
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	projects := make([]*VendoredProject, len(manifest.Package))
+	for i, pkg := range manifest.Package {
+		projects[i] = &VendoredProject{
+			Root:     pkg.Path,
+			Revision: pkg.Revision,
+			Version:  pkg.VersionExact,
+			Source:   pkg.Origin,
+		}
+		if projects[i].Version == "" {
+			projects[i].Version = pkg.Version
+		}
+	}
+	return projects, nil
+}
+
+// parseGodeps parses a Godeps/Godeps.json file written by godep.
+func parseGodeps(r io.Reader) ([]*VendoredProject, error) {
+	var manifest struct {
+		Deps []struct {
+			ImportPath string
+			Comment    string
+			Rev        string
+		}
+	}
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	projects := make([]*VendoredProject, len(manifest.Deps))
+	for i, dep := range manifest.Deps {
+		projects[i] = &VendoredProject{
+			Root:     dep.ImportPath,
+			Revision: dep.Rev,
+			Version:  dep.Comment,
+		}
+	}
+	return projects, nil
+}
+
+// findSubrepos finds any projects vendored with git-subrepo below the vendor directory.
+func findSubrepos(vendorDir string) ([]*VendoredProject, error) {
+	var projects []*VendoredProject
+	err := filepath.Walk(vendorDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == vendorDir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != ".gitrepo" {
+			return nil
+		}
+		root, err := filepath.Rel(vendorDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		p := &VendoredProject{Root: filepath.ToSlash(root)}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			i := strings.IndexByte(line, '=')
+			if i < 0 || strings.HasPrefix(line, ";") {
+				continue
+			}
+			value := strings.TrimSpace(line[i+1:])
+			switch strings.TrimSpace(line[:i]) {
+			case "remote":
+				p.Source = value
+			case "commit":
+				p.Revision = value
+			case "branch":
+				p.Version = value
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		projects = append(projects, p)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding git-subrepo projects")
+	}
+	return projects, nil
+}
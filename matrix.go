@@ -0,0 +1,71 @@
+package gdl
+
+// A PackageDeps is the dependencies of one of the listed packages.
+type PackageDeps struct {
+	ImportPath string
+	Direct     []string // dependencies imported by the package
+	Transitive []string // dependencies only imported through other packages
+}
+
+// A DependencyUsers is the listed packages that use a dependency.
+type DependencyUsers struct {
+	ImportPath string
+	Root       string
+	Direct     []string // listed packages that import the dependency
+	Transitive []string // listed packages that only use the dependency through other packages
+}
+
+// Matrix returns the direct and transitive dependencies of each listed package,
+// limited to the dependencies in deps.
+// Test imports of the listed packages, and their dependencies, are included if tests is true.
+func Matrix(l *Listing, deps []*Dependency, tests bool) []*PackageDeps {
+	matrix := make([]*PackageDeps, 0, len(l.Packages))
+	for _, pkg := range l.Packages {
+		imports := l.Imports(pkg, tests)
+		reachable := make(map[string]bool, len(pkg.Deps))
+		for _, path := range pkg.Deps {
+			path, _ = unvendor(l.Current, path)
+			reachable[path] = true
+		}
+		for path, test := range imports {
+			if !test {
+				continue
+			}
+			if tp, ok := l.All[path]; ok {
+				for _, dep := range tp.Deps {
+					dep, _ = unvendor(l.Current, dep)
+					reachable[dep] = true
+				}
+			}
+		}
+		pd := &PackageDeps{ImportPath: pkg.ImportPath}
+		for _, d := range deps {
+			if _, ok := imports[d.ImportPath]; ok {
+				pd.Direct = append(pd.Direct, d.ImportPath)
+			} else if reachable[d.ImportPath] {
+				pd.Transitive = append(pd.Transitive, d.ImportPath)
+			}
+		}
+		matrix = append(matrix, pd)
+	}
+	return matrix
+}
+
+// Users returns the listed packages that use each of the dependencies, from the matrix of the listed packages.
+func Users(matrix []*PackageDeps, deps []*Dependency) []*DependencyUsers {
+	users := make(map[string]*DependencyUsers, len(deps))
+	list := make([]*DependencyUsers, len(deps))
+	for i, d := range deps {
+		list[i] = &DependencyUsers{ImportPath: d.ImportPath, Root: d.Root}
+		users[d.ImportPath] = list[i]
+	}
+	for _, pd := range matrix {
+		for _, path := range pd.Direct {
+			users[path].Direct = append(users[path].Direct, pd.ImportPath)
+		}
+		for _, path := range pd.Transitive {
+			users[path].Transitive = append(users[path].Transitive, pd.ImportPath)
+		}
+	}
+	return list
+}
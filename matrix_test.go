@@ -0,0 +1,64 @@
+package gdl_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nathanielc/gdl"
+	"github.com/nathanielc/gdl/internal/gdltest"
+)
+
+func TestMatrix(t *testing.T) {
+	gopath, err := filepath.Abs(filepath.Join("testdata", "gopath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := gdl.List(context.Background(), gdl.Options{
+		ImportPaths: []string{"./..."},
+		Dir:         filepath.Join(gopath, "src", "example.com", "app"),
+		Standard:    true,
+		Tests:       true,
+		Runner:      gdltest.Go{Testdata: "testdata", GOPATH: gopath},
+		Resolver:    gdltest.Repos,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix := gdl.Matrix(res.Listing, res.Dependencies, true)
+	want := []*gdl.PackageDeps{
+		{
+			ImportPath: "example.com/app",
+			Direct:     []string{"fmt", "github.com/foo/assert", "github.com/foo/bar/baz", "github.com/pkg/errors", "testing"},
+			Transitive: []string{"errors", "github.com/foo/bar", "io", "strings"},
+		},
+		{
+			ImportPath: "example.com/app/internal/util",
+			Direct:     []string{"strings"},
+			Transitive: []string{"errors", "io"},
+		},
+	}
+	if len(matrix) != len(want) {
+		t.Fatalf("unexpected number of packages, got %d want %d", len(matrix), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(matrix[i], want[i]) {
+			t.Errorf("unexpected dependencies of %s:\ngot:  %+v\nwant: %+v", want[i].ImportPath, matrix[i], want[i])
+		}
+	}
+
+	users := make(map[string]*gdl.DependencyUsers)
+	for _, u := range gdl.Users(matrix, res.Dependencies) {
+		users[u.ImportPath] = u
+	}
+	wantUsers := map[string]*gdl.DependencyUsers{
+		"strings":            {ImportPath: "strings", Root: "standard", Direct: []string{"example.com/app/internal/util"}, Transitive: []string{"example.com/app"}},
+		"github.com/foo/bar": {ImportPath: "github.com/foo/bar", Root: "github.com/foo/bar", Transitive: []string{"example.com/app"}},
+	}
+	for path, want := range wantUsers {
+		if got := users[path]; !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected users of %s:\ngot:  %+v\nwant: %+v", path, got, want)
+		}
+	}
+}
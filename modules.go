@@ -0,0 +1,90 @@
+package gdl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Taken from golang.org/go/cmd/go
+// A Module describes a single module as reported by 'go list -m -json'.
+type Module struct {
+	Path      string       `json:",omitempty"` // module path
+	Version   string       `json:",omitempty"` // module version
+	Versions  []string     `json:",omitempty"` // available module versions (with -versions)
+	Replace   *Module      `json:",omitempty"` // replaced by this module
+	Time      *time.Time   `json:",omitempty"` // time version was created
+	Update    *Module      `json:",omitempty"` // available update, if any (with -u)
+	Main      bool         `json:",omitempty"` // is this the main module?
+	Indirect  bool         `json:",omitempty"` // is this module only an indirect dependency of main module?
+	Dir       string       `json:",omitempty"` // directory holding files for this module, if any
+	GoMod     string       `json:",omitempty"` // path to go.mod file for this module, if any
+	GoVersion string       `json:",omitempty"` // go version used in module
+	Error     *ModuleError `json:",omitempty"` // error loading module
+}
+
+// A ModuleError describes an error loading information about a module.
+type ModuleError struct {
+	Err string // the error itself
+}
+
+// String returns the module path and version, if any.
+func (m *Module) String() string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + " " + m.Version
+}
+
+// moduleMode reports whether the go command is running in module mode for the directory of the go tool.
+func moduleMode(ctx context.Context, g goTool) (bool, error) {
+	out, err := g.run(ctx, "env", "GOMOD")
+	if err != nil {
+		return false, errors.Wrap(err, "go env cmd failed")
+	}
+	gomod := strings.TrimSpace(string(out))
+	return gomod != "" && gomod != os.DevNull, nil
+}
+
+// buildList returns the modules in the build list of the main module by module path.
+// The build list cannot be computed when building from the vendor directory,
+// the modules reported with each package are returned instead along with a warning.
+func buildList(ctx context.Context, g goTool, packages map[string]*Package) (map[string]*Module, []string, error) {
+	modules, err := listModules(ctx, g)
+	if err == nil {
+		return modules, nil, nil
+	}
+	if !strings.Contains(err.Error(), "using the vendor directory") {
+		return nil, nil, errors.Wrap(err, "listing modules")
+	}
+	modules = make(map[string]*Module)
+	for _, pkg := range packages {
+		if pkg.Module != nil {
+			modules[pkg.Module.Path] = pkg.Module
+		}
+	}
+	return modules, []string{"the build list cannot be computed from the vendor directory, indirect modules are not reported"}, nil
+}
+
+// List all modules in the build list of the main module.
+func listModules(ctx context.Context, g goTool) (map[string]*Module, error) {
+	out, err := g.list(ctx, "-m", "-e", "-json", "all")
+	if err != nil {
+		return nil, errors.Wrap(err, "go list cmd failed")
+	}
+	modules := make(map[string]*Module)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		m := &Module{}
+		if err := dec.Decode(m); err != nil {
+			return nil, errors.Wrap(err, "invalid json go list cmd")
+		}
+		modules[m.Path] = m
+	}
+	return modules, nil
+}
@@ -0,0 +1,31 @@
+package gdl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// errRunner fails every command with err.
+type errRunner struct {
+	err error
+}
+
+func (r errRunner) Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	return nil, r.err
+}
+
+func TestBuildList(t *testing.T) {
+	packages := map[string]*Package{"example.com/a": {ImportPath: "example.com/a", Module: &Module{Path: "example.com/a"}}}
+	vendorErr := errors.New("exit status 1: go: can't compute 'all' using the vendor directory\n\t(Use -mod=mod or -mod=readonly to bypass.)")
+	modules, warnings, err := buildList(context.Background(), goTool{Runner: errRunner{vendorErr}}, packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules["example.com/a"] == nil || len(warnings) != 1 {
+		t.Errorf("expected the modules of the packages with a warning, got %v %v", modules, warnings)
+	}
+	if _, _, err := buildList(context.Background(), goTool{Runner: errRunner{errors.New("exit status 1: go: updates to go.mod needed")}}, packages); err == nil {
+		t.Error("expected an error")
+	}
+}
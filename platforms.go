@@ -0,0 +1,169 @@
+package gdl
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Runner runs the go command.
+type Runner interface {
+	// Run runs go with the args from the directory, with the additional environment variables,
+	// and returns its standard output.
+	Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error)
+}
+
+// execRunner runs the go binary found in PATH.
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, err := cmd.Output()
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		return nil, errors.Errorf("%v: %s", err, bytes.TrimSpace(ee.Stderr))
+	}
+	return out, err
+}
+
+// A goTool runs the go command from a directory for a platform and build tags.
+type goTool struct {
+	Runner Runner
+	Dir    string   // directory to run from
+	Env    []string // additional environment variables
+	GOOS   string   // empty for the host platform
+	GOARCH string
+	Tags   string // comma separated build tags
+	Cgo    bool   // enable cgo on the host platform unless CGO_ENABLED is set
+}
+
+// targets returns a go tool for each of the GOOS/GOARCH platforms.
+// Returns the host platform if no platforms are given.
+func (g goTool) targets(platforms []string) ([]goTool, error) {
+	if len(platforms) == 0 {
+		return []goTool{g}, nil
+	}
+	var targets []goTool
+	for _, platform := range platforms {
+		parts := strings.Split(strings.TrimSpace(platform), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid platform %q, expected GOOS/GOARCH", platform)
+		}
+		t := g
+		t.GOOS, t.GOARCH = parts[0], parts[1]
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Platform returns the GOOS/GOARCH of the target, or an empty string for the host platform.
+func (g goTool) Platform() string {
+	if g.GOOS == "" {
+		return ""
+	}
+	return g.GOOS + "/" + g.GOARCH
+}
+
+// run runs the go command with the args and returns its output.
+func (g goTool) run(ctx context.Context, args ...string) ([]byte, error) {
+	env := g.Env
+	if g.GOOS != "" || g.Cgo {
+		env = nil
+		// The go command disables cgo when cross compiling or without a C compiler, which would drop
+		// the cgo files and their imports. Listing never needs a C compiler, so enable it unless explicitly set.
+		if _, ok := os.LookupEnv("CGO_ENABLED"); !ok {
+			env = append(env, "CGO_ENABLED=1")
+		}
+		env = append(env, g.Env...)
+	}
+	if g.GOOS != "" {
+		env = append(env, "GOOS="+g.GOOS, "GOARCH="+g.GOARCH)
+	}
+	return g.Runner.Run(ctx, g.Dir, env, args...)
+}
+
+// cgoDisabled reports whether CGO_ENABLED=0 is set in the environment, or in the additional environment variables.
+func cgoDisabled(env []string) bool {
+	value := os.Getenv("CGO_ENABLED")
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "CGO_ENABLED=") {
+			value = strings.TrimPrefix(kv, "CGO_ENABLED=")
+		}
+	}
+	return value == "0"
+}
+
+// list runs 'go list' with the args for the platform and build tags and returns its output.
+func (g goTool) list(ctx context.Context, args ...string) ([]byte, error) {
+	listArgs := []string{"list"}
+	if g.Tags != "" {
+		listArgs = append(listArgs, "-tags", g.Tags)
+	}
+	return g.run(ctx, append(listArgs, args...)...)
+}
+
+// findTargetDeps finds the dependencies of the packages for each target, returning the union of the listings
+// and the platforms that need each dependency by import path.
+// The platforms are nil when listing for the host platform only.
+func findTargetDeps(ctx context.Context, targets []goTool, standards, tests, skipVendored bool, importPaths ...string) (*Listing, map[string][]string, error) {
+	if len(targets) == 1 && targets[0].GOOS == "" {
+		listing, err := findDeps(ctx, targets[0], standards, tests, skipVendored, importPaths...)
+		return listing, nil, err
+	}
+	merged := &Listing{All: make(map[string]*Package), Direct: make(map[string]bool)}
+	platforms := make(map[string][]string)
+	listed := make(map[string]bool)
+	warned := make(map[string]bool)
+	for _, t := range targets {
+		l, err := findDeps(ctx, t, standards, tests, skipVendored, importPaths...)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "listing %s", t.Platform())
+		}
+		merged.Current = l.Current
+		for _, pkg := range l.Packages {
+			if !listed[pkg.ImportPath] {
+				listed[pkg.ImportPath] = true
+				merged.Packages = append(merged.Packages, pkg)
+			}
+		}
+		for _, dep := range l.Deps {
+			if _, ok := platforms[dep.ImportPath]; !ok {
+				merged.Deps = append(merged.Deps, dep)
+			}
+			platforms[dep.ImportPath] = append(platforms[dep.ImportPath], t.Platform())
+		}
+		for path := range l.Direct {
+			merged.Direct[path] = true
+		}
+		for path, pkg := range l.All {
+			if _, ok := merged.All[path]; !ok {
+				merged.All[path] = pkg
+			}
+		}
+		for _, w := range l.Warnings {
+			if !warned[w] {
+				warned[w] = true
+				merged.Warnings = append(merged.Warnings, w)
+			}
+		}
+		if l.Modules != nil {
+			if merged.Modules == nil {
+				merged.Modules = make(map[string]*Module, len(l.Modules))
+			}
+			for path, m := range l.Modules {
+				merged.Modules[path] = m
+			}
+		}
+	}
+	sort.Sort(merged.Packages)
+	sort.Sort(merged.Deps)
+	return merged, platforms, nil
+}
@@ -0,0 +1,47 @@
+package gdl
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// envRunner records the additional environment variables of the last run.
+type envRunner struct {
+	env *[]string
+}
+
+func (r envRunner) Run(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	*r.env = env
+	return nil, nil
+}
+
+func TestGoToolEnv(t *testing.T) {
+	if _, ok := os.LookupEnv("CGO_ENABLED"); ok {
+		t.Skip("CGO_ENABLED is set")
+	}
+	var env []string
+	testCases := []struct {
+		name string
+		g    goTool
+		want []string
+	}{
+		{name: "host", g: goTool{Env: []string{"GOFLAGS=-mod=mod"}}, want: []string{"GOFLAGS=-mod=mod"}},
+		{name: "cgo", g: goTool{Cgo: true}, want: []string{"CGO_ENABLED=1"}},
+		{name: "platform", g: goTool{GOOS: "linux", GOARCH: "arm64"}, want: []string{"CGO_ENABLED=1", "GOOS=linux", "GOARCH=arm64"}},
+		{name: "disabled", g: goTool{Cgo: true, Env: []string{"CGO_ENABLED=0"}}, want: []string{"CGO_ENABLED=1", "CGO_ENABLED=0"}},
+	}
+	for _, tc := range testCases {
+		tc.g.Runner = envRunner{&env}
+		if _, err := tc.g.run(context.Background(), "env"); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(env, tc.want) {
+			t.Errorf("%s: got env %v, want %v", tc.name, env, tc.want)
+		}
+	}
+	if !cgoDisabled([]string{"CGO_ENABLED=0"}) || cgoDisabled([]string{"CGO_ENABLED=1"}) {
+		t.Error("unexpected cgoDisabled result")
+	}
+}
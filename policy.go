@@ -0,0 +1,269 @@
+package gdl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of policy rules, each matching a single attribute of a dependency.
+var policyKinds = map[string]bool{
+	"import":   true, // import path
+	"host":     true, // first element of the repo root, i.e. github.com
+	"vcs":      true, // version control system, i.e. git
+	"vendored": true, // yes or no
+	"license":  true, // SPDX identifier of each license, or None or Unknown
+}
+
+// A PolicyRule allows or denies the dependencies matching a pattern on a single attribute.
+type PolicyRule struct {
+	Allow   bool   // allow, otherwise deny
+	Kind    string // one of import, host, vcs, vendored or license
+	Pattern string // glob as with path.Match, a trailing /... also matches everything below
+	Pos     string // file and line of the rule
+}
+
+func (r *PolicyRule) String() string {
+	action := "deny"
+	if r.Allow {
+		action = "allow"
+	}
+	return action + " " + r.Kind + " " + r.Pattern
+}
+
+// A Policy is a list of rules the dependencies must follow.
+//
+// A dependency violates the policy if it matches any deny rule,
+// or if there are allow rules of a kind and it matches none of them.
+// Standard packages are only checked against import rules, the pattern std matches every standard package.
+// Dependencies in modules have no vcs and are not checked against vcs rules.
+type Policy struct {
+	Rules []*PolicyRule
+}
+
+// ReadPolicy reads a policy file, with a rule per line in the form:
+//
+//	allow|deny KIND PATTERN
+//
+// Blank lines and lines starting with # are ignored.
+func ReadPolicy(file string) (*Policy, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening policy file")
+	}
+	defer f.Close()
+	p := &Policy{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", file, line)
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("%s: expected allow|deny KIND PATTERN, got %q", pos, text)
+		}
+		r := &PolicyRule{Kind: fields[1], Pattern: fields[2], Pos: pos}
+		switch fields[0] {
+		case "allow":
+			r.Allow = true
+		case "deny":
+		default:
+			return nil, errors.Errorf("%s: unknown action %q, expected allow or deny", pos, fields[0])
+		}
+		if !policyKinds[r.Kind] {
+			return nil, errors.Errorf("%s: unknown kind %q, expected one of import, host, vcs, vendored or license", pos, r.Kind)
+		}
+		if _, err := path.Match(strings.TrimSuffix(r.Pattern, "/..."), ""); err != nil {
+			return nil, errors.Errorf("%s: invalid pattern %q", pos, r.Pattern)
+		}
+		p.Rules = append(p.Rules, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading policy file")
+	}
+	return p, nil
+}
+
+// A Violation is a dependency that does not follow a rule of the policy.
+type Violation struct {
+	ImportPath string
+	Root       string
+	Rule       string // the deny rule matched, or the allow rules not matched
+	Pos        string // positions of the rules in the policy file
+	Reason     string
+}
+
+// policyValues returns the values of the dependency for the kind,
+// or nil if the dependency is not checked against rules of the kind.
+func policyValues(d *Dependency, kind string) []string {
+	if d.Standard && kind != "import" {
+		return nil
+	}
+	switch kind {
+	case "import":
+		return []string{d.ImportPath}
+	case "host":
+		return []string{strings.SplitN(d.Root, "/", 2)[0]}
+	case "vcs":
+		if d.Module != "" {
+			// Modules are downloaded from the module proxy, not a repo
+			return nil
+		}
+		values := []string{strings.ToLower(d.VCS)}
+		if d.repo != nil && d.repo.VCS.Cmd != "" {
+			values = append(values, d.repo.VCS.Cmd)
+		}
+		return values
+	case "vendored":
+		if d.Vendored {
+			return []string{"yes"}
+		}
+		return []string{"no"}
+	case "license":
+		if d.License == "" {
+			return []string{"None"}
+		}
+		return strings.Split(d.License, ", ")
+	}
+	return nil
+}
+
+// matchRule reports whether the rule matches the value of the dependency.
+func matchRule(r *PolicyRule, d *Dependency, value string) bool {
+	if r.Kind == "import" && r.Pattern == "std" {
+		return d.Standard
+	}
+	pattern := r.Pattern
+	if r.Kind == "vcs" {
+		pattern = strings.ToLower(pattern)
+	}
+	if strings.HasSuffix(pattern, "/...") {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		if ok, _ := path.Match(prefix, value); ok {
+			return true
+		}
+		// Match the prefix against the leading elements of the value
+		for i := range value {
+			if value[i] == '/' {
+				if ok, _ := path.Match(prefix, value[:i]); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// Check returns the violations of the policy by the dependencies.
+// A dependency with several licenses must have every license allowed.
+func (p *Policy) Check(deps []*Dependency) []*Violation {
+	allowed := make(map[string][]*PolicyRule)
+	for _, r := range p.Rules {
+		if r.Allow {
+			allowed[r.Kind] = append(allowed[r.Kind], r)
+		}
+	}
+	var violations []*Violation
+	for _, d := range deps {
+		for _, r := range p.Rules {
+			if r.Allow {
+				continue
+			}
+			for _, v := range policyValues(d, r.Kind) {
+				if matchRule(r, d, v) {
+					violations = append(violations, &Violation{
+						ImportPath: d.ImportPath,
+						Root:       d.Root,
+						Rule:       r.String(),
+						Pos:        r.Pos,
+						Reason:     fmt.Sprintf("%s %s is denied", r.Kind, v),
+					})
+					break
+				}
+			}
+		}
+		for _, kind := range []string{"import", "host", "vcs", "vendored", "license"} {
+			rules := allowed[kind]
+			if len(rules) == 0 {
+				continue
+			}
+			values := policyValues(d, kind)
+			if kind == "vcs" {
+				// The name and command are the same vcs, either may be allowed
+				if len(values) == 0 || matchAny(rules, d, values) {
+					continue
+				}
+				values = values[:1]
+			}
+			for _, v := range values {
+				if matchAny(rules, d, []string{v}) {
+					continue
+				}
+				patterns := make([]string, len(rules))
+				positions := make([]string, len(rules))
+				for i, r := range rules {
+					patterns[i] = r.Pattern
+					positions[i] = r.Pos
+				}
+				violations = append(violations, &Violation{
+					ImportPath: d.ImportPath,
+					Root:       d.Root,
+					Rule:       "allow " + kind + " " + strings.Join(patterns, " "),
+					Pos:        strings.Join(positions, " "),
+					Reason:     fmt.Sprintf("%s %s is not allowed", kind, v),
+				})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// matchAny reports whether any of the rules match any of the values.
+func matchAny(rules []*PolicyRule, d *Dependency, values []string) bool {
+	for _, r := range rules {
+		for _, v := range values {
+			if matchRule(r, d, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check lists the dependencies, always including the standard packages,
+// and returns the violations of the policy.
+func Check(ctx context.Context, opts Options, policy *Policy) ([]*Violation, error) {
+	opts.Standard = true
+	licenses := opts.Licenses
+	for _, r := range policy.Rules {
+		licenses = licenses || r.Kind == "license"
+	}
+	// Standard packages are not checked against license rules
+	opts.Licenses = false
+	res, err := List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if licenses {
+		var deps []*Dependency
+		for _, d := range res.Dependencies {
+			if !d.Standard {
+				deps = append(deps, d)
+			}
+		}
+		if err := findLicenses(deps); err != nil {
+			return nil, err
+		}
+	}
+	return policy.Check(res.Dependencies), nil
+}
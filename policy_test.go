@@ -0,0 +1,84 @@
+package gdl_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nathanielc/gdl"
+	"github.com/nathanielc/gdl/internal/gdltest"
+)
+
+func TestCheck(t *testing.T) {
+	gopath, err := filepath.Abs(filepath.Join("testdata", "gopath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := gdl.ReadPolicy(filepath.Join("testdata", "policy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := gdl.Check(context.Background(), gdl.Options{
+		ImportPaths: []string{"./..."},
+		Dir:         filepath.Join(gopath, "src", "example.com", "app"),
+		Tests:       true,
+		Runner:      gdltest.Go{Testdata: "testdata", GOPATH: gopath},
+		Resolver:    gdltest.Repos,
+	}, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]string
+	for _, v := range violations {
+		got = append(got, [2]string{v.ImportPath, v.Reason})
+	}
+	want := [][2]string{
+		{"github.com/foo/assert", "vcs mercurial is not allowed"},
+		{"github.com/foo/assert", "license None is not allowed"},
+		{"github.com/foo/bar", "import github.com/foo/bar is denied"},
+		{"github.com/foo/bar/baz", "import github.com/foo/bar/baz is denied"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected violations:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestReadPolicyErrors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown action": "permit import foo",
+		"unknown kind":   "deny owner foo",
+		"missing fields": "deny import",
+		"bad pattern":    "deny import [",
+	}
+	for name, rule := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "policy")
+			if err := ioutil.WriteFile(file, []byte(rule+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := gdl.ReadPolicy(file); err == nil {
+				t.Errorf("expected an error reading %q", rule)
+			}
+		})
+	}
+}
+
+func TestCheckModules(t *testing.T) {
+	policy := &gdl.Policy{Rules: []*gdl.PolicyRule{
+		{Allow: true, Kind: "vcs", Pattern: "git"},
+		{Allow: true, Kind: "host", Pattern: "github.com"},
+	}}
+	deps := []*gdl.Dependency{
+		{ImportPath: "github.com/pkg/errors", Root: "github.com/pkg/errors", VCS: "Module", Module: "github.com/pkg/errors"},
+		{ImportPath: "golang.org/x/tools/go/vcs", Root: "golang.org/x/tools", VCS: "Module", Module: "golang.org/x/tools"},
+	}
+	violations := policy.Check(deps)
+	if len(violations) != 1 || violations[0].ImportPath != "golang.org/x/tools/go/vcs" || violations[0].Reason != "host golang.org is not allowed" {
+		for _, v := range violations {
+			t.Logf("%+v", v)
+		}
+		t.Errorf("expected only the host of golang.org/x/tools/go/vcs to violate the policy")
+	}
+}
@@ -1,12 +1,30 @@
-package main
+package gdl
 
 import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
 	"github.com/pkg/errors"
 	"golang.org/x/tools/go/vcs"
 )
 
-func findRepos(packages []*Package) ([]*vcs.RepoRoot, error) {
+// A Resolver finds the repo of an import path.
+type Resolver interface {
+	RepoRoot(importPath string) (*vcs.RepoRoot, error)
+}
+
+// vcsResolver resolves repos as 'go get' does, using the network for custom import paths.
+type vcsResolver struct{}
+
+func (vcsResolver) RepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	return vcs.RepoRootForImportPath(importPath, false)
+}
+
+func findRepos(ctx context.Context, packages []*Package, cache *repoCache, resolver Resolver, concurrency int) ([]*vcs.RepoRoot, error) {
 	repos := make([]*vcs.RepoRoot, len(packages))
+	var pending []string
 	for i, pkg := range packages {
 		if pkg.Standard {
 			repos[i] = &vcs.RepoRoot{
@@ -14,13 +32,193 @@ func findRepos(packages []*Package) ([]*vcs.RepoRoot, error) {
 				Repo: "standard",
 				Root: "standard",
 			}
-		} else {
-			repo, err := vcs.RepoRootForImportPath(pkg.ImportPath, false)
-			if err != nil {
-				return nil, errors.Wrapf(err, "could not determine repo for %s", pkg.ImportPath)
+		} else if pkg.Module != nil {
+			// The module path is the root, no need to ask the network
+			repos[i] = &vcs.RepoRoot{
+				VCS:  &vcs.Cmd{Name: "Module"},
+				Repo: pkg.Module.Path,
+				Root: pkg.Module.Path,
 			}
+		} else if repo, ok := cache.lookup(pkg.ImportPath); ok {
 			repos[i] = repo
+		} else if cache.offline {
+			repos[i] = offlineRepoRoot(pkg.ImportPath)
+		} else {
+			pending = append(pending, pkg.ImportPath)
+		}
+	}
+
+	resolved, err := resolveRepos(ctx, pending, cache, resolver, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	for i, pkg := range packages {
+		if repos[i] == nil {
+			repos[i] = resolved[pkg.ImportPath]
 		}
 	}
+	if err := cache.save(); err != nil {
+		return nil, err
+	}
 	return repos, nil
 }
+
+// staticRepoPaths are the import paths whose repo root 'go get' knows without the network,
+// as in golang.org/x/tools/go/vcs. The vcs is only known for some of the hosts.
+var staticRepoPaths = []struct {
+	re  *regexp.Regexp
+	vcs string // vcs command, empty if unknown
+}{
+	{re: regexp.MustCompile(`^(?P<root>github\.com/[A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+)(/[\p{L}0-9_.\-]+)*$`), vcs: "git"},
+	{re: regexp.MustCompile(`^(?P<root>bitbucket\.org/[A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+)(/[A-Za-z0-9_.\-]+)*$`)},
+	{re: regexp.MustCompile(`^(?P<root>launchpad\.net/([A-Za-z0-9_.\-]+(/[A-Za-z0-9_.\-]+)?|~[A-Za-z0-9_.\-]+/(\+junk|[A-Za-z0-9_.\-]+)/[A-Za-z0-9_.\-]+))(/[A-Za-z0-9_.\-]+)*$`), vcs: "bzr"},
+	{re: regexp.MustCompile(`^(?P<root>git\.openstack\.org/[A-Za-z0-9_.\-]+/[A-Za-z0-9_.\-]+)(\.git)?(/[A-Za-z0-9_.\-]+)*$`), vcs: "git"},
+	// General syntax for any server, the scheme of the repo url is unknown without the network
+	{re: regexp.MustCompile(`^(?P<root>([a-z0-9.\-]+\.)+[a-z0-9.\-]+(:[0-9]+)?/[A-Za-z0-9_.\-/]*?\.(?P<vcs>bzr|git|hg|svn))(/[A-Za-z0-9_.\-]+)*$`)},
+}
+
+// offlineRepoRoot returns the repo of the import path without using the network.
+// The repo root of a custom import path is unknown, so the import path itself is used as the root.
+func offlineRepoRoot(importPath string) *vcs.RepoRoot {
+	if repo, ok := staticRepoRoot(importPath); ok {
+		return repo
+	}
+	return &vcs.RepoRoot{VCS: &vcs.Cmd{Name: "Unknown"}, Root: importPath}
+}
+
+// staticRepoRoot returns the repo of an import path of a well known host.
+func staticRepoRoot(importPath string) (*vcs.RepoRoot, bool) {
+	for _, p := range staticRepoPaths {
+		m := p.re.FindStringSubmatch(importPath)
+		if m == nil {
+			continue
+		}
+		repo := &vcs.RepoRoot{VCS: &vcs.Cmd{Name: "Unknown"}, Root: m[p.re.SubexpIndex("root")]}
+		cmd := p.vcs
+		if i := p.re.SubexpIndex("vcs"); i >= 0 {
+			cmd = m[i]
+		} else {
+			repo.Repo = "https://" + repo.Root
+		}
+		if c := vcs.ByCmd(cmd); c != nil {
+			repo.VCS = c
+		}
+		return repo, true
+	}
+	return nil, false
+}
+
+// candidateRoot guesses the repo root of the import path before it is resolved.
+// Other import paths than those of well known hosts are guessed to have the repo root
+// at the third element, i.e. golang.org/x/tools.
+func candidateRoot(importPath string) string {
+	if repo, ok := staticRepoRoot(importPath); ok {
+		return repo.Root
+	}
+	if parts := strings.SplitN(importPath, "/", 4); len(parts) == 4 {
+		return strings.Join(parts[:3], "/")
+	}
+	return importPath
+}
+
+// resolveRepos resolves the repos of the import paths using at most concurrency lookups at once.
+// Resolved repos are added to the cache, and an import path below an already resolved repo root,
+// or with the same candidate root as a currently resolving import path, waits for that result
+// instead of being looked up again.
+// No more lookups are started once the context is done.
+func resolveRepos(ctx context.Context, importPaths []string, cache *repoCache, resolver Resolver, concurrency int) (map[string]*vcs.RepoRoot, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		mu       sync.Mutex
+		next     int
+		firstErr error
+		resolved = make(map[string]*vcs.RepoRoot, len(importPaths))
+		roots    = make(map[string]*vcs.RepoRoot) // resolved during this run, used regardless of the cache ttl
+		inFlight = make(map[string]chan struct{}) // lookups in progress by candidate root
+	)
+	resolve := func(importPath string) {
+		candidate := candidateRoot(importPath)
+		mu.Lock()
+		for {
+			if repo, ok := lookupRoot(roots, importPath); ok {
+				resolved[importPath] = repo
+				mu.Unlock()
+				return
+			}
+			if repo, ok := cache.lookup(importPath); ok {
+				resolved[importPath] = repo
+				mu.Unlock()
+				return
+			}
+			wait, ok := inFlight[candidate]
+			if !ok {
+				break
+			}
+			mu.Unlock()
+			<-wait
+			mu.Lock()
+		}
+		done := make(chan struct{})
+		inFlight[candidate] = done
+		mu.Unlock()
+
+		repo, err := resolver.RepoRoot(importPath)
+
+		mu.Lock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "could not determine repo for %s", importPath)
+			}
+		} else {
+			cache.add(repo)
+			roots[repo.Root] = repo
+			resolved[importPath] = repo
+		}
+		delete(inFlight, candidate)
+		close(done)
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(importPaths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if firstErr == nil && ctx.Err() != nil {
+					firstErr = ctx.Err()
+				}
+				if next == len(importPaths) || firstErr != nil {
+					mu.Unlock()
+					return
+				}
+				importPath := importPaths[next]
+				next++
+				mu.Unlock()
+				resolve(importPath)
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return resolved, nil
+}
+
+// lookupRoot returns the repo of the longest root that is a prefix of the import path.
+func lookupRoot(roots map[string]*vcs.RepoRoot, importPath string) (*vcs.RepoRoot, bool) {
+	for root := importPath; ; {
+		if repo, ok := roots[root]; ok {
+			return repo, true
+		}
+		i := strings.LastIndexByte(root, '/')
+		if i < 0 {
+			return nil, false
+		}
+		root = root[:i]
+	}
+}
@@ -0,0 +1,113 @@
+package gdl
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// countingResolver resolves every import path below root to the same repo, counting the lookups.
+type countingResolver struct {
+	mu    sync.Mutex
+	root  string
+	delay time.Duration // duration of each lookup
+	calls int
+}
+
+func (r *countingResolver) RepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+	time.Sleep(r.delay)
+	return &vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://" + r.root, Root: r.root}, nil
+}
+
+func TestResolveReposOncePerRoot(t *testing.T) {
+	testCases := []struct {
+		root        string
+		importPaths []string
+		concurrency int
+	}{
+		{root: "github.com/a/b", importPaths: []string{"github.com/a/b", "github.com/a/b/c", "github.com/a/b/c/d"}, concurrency: 1},
+		{root: "github.com/a/b", importPaths: []string{"github.com/a/b/c", "github.com/a/b/d", "github.com/a/b/e", "github.com/a/b/e/f"}, concurrency: 8},
+		{root: "golang.org/x/tools", importPaths: []string{"golang.org/x/tools/go/vcs", "golang.org/x/tools/cover", "golang.org/x/tools/go/packages", "golang.org/x/tools/imports"}, concurrency: 8},
+	}
+	for _, tc := range testCases {
+		for _, ttl := range []time.Duration{0, time.Hour} {
+			cache, _, err := openRepoCache("", ttl, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resolver := &countingResolver{root: tc.root, delay: 10 * time.Millisecond}
+			resolved, err := resolveRepos(context.Background(), tc.importPaths, cache, resolver, tc.concurrency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolver.calls != 1 {
+				t.Errorf("%s, concurrency %d, ttl %v: expected 1 lookup, got %d", tc.root, tc.concurrency, ttl, resolver.calls)
+			}
+			for _, path := range tc.importPaths {
+				if repo := resolved[path]; repo == nil || repo.Root != tc.root {
+					t.Errorf("%s, ttl %v: unexpected repo for %s: %+v", tc.root, ttl, path, repo)
+				}
+			}
+		}
+	}
+}
+
+func TestOfflineRepoRoot(t *testing.T) {
+	testCases := []struct {
+		importPath string
+		root       string
+		vcs        string
+		repo       string
+	}{
+		{importPath: "github.com/foo/bar/baz", root: "github.com/foo/bar", vcs: "Git", repo: "https://github.com/foo/bar"},
+		{importPath: "bitbucket.org/foo/bar/baz", root: "bitbucket.org/foo/bar", vcs: "Unknown", repo: "https://bitbucket.org/foo/bar"},
+		{importPath: "launchpad.net/~foo/bar/trunk/baz", root: "launchpad.net/~foo/bar/trunk", vcs: "Bazaar", repo: "https://launchpad.net/~foo/bar/trunk"},
+		{importPath: "example.com/foo/bar.hg/baz", root: "example.com/foo/bar.hg", vcs: "Mercurial"},
+		{importPath: "golang.org/x/net/context", root: "golang.org/x/net/context", vcs: "Unknown"},
+	}
+	for _, tc := range testCases {
+		repo := offlineRepoRoot(tc.importPath)
+		if repo.Root != tc.root || repo.VCS.Name != tc.vcs || repo.Repo != tc.repo {
+			t.Errorf("%s: unexpected repo root %s, vcs %s, repo %s", tc.importPath, repo.Root, repo.VCS.Name, repo.Repo)
+		}
+	}
+}
+
+func TestRepoCacheInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+	if err := ioutil.WriteFile(path, []byte("{\"github.com/a/b\": {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, warnings, err := openRepoCache(path, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || len(cache.entries) != 0 {
+		t.Fatalf("expected an empty cache with a warning, got %v %v", cache.entries, warnings)
+	}
+
+	// Saving replaces the invalid cache without leaving temporary files behind
+	cache.add(&vcs.RepoRoot{VCS: vcs.ByCmd("git"), Repo: "https://github.com/a/b", Root: "github.com/a/b"})
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+	cache, warnings, err = openRepoCache(path, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.lookup("github.com/a/b/c"); !ok || len(warnings) != 0 {
+		t.Errorf("expected the saved repo without warnings, got %v %v", cache.entries, warnings)
+	}
+	if infos, err := ioutil.ReadDir(dir); err != nil || len(infos) != 1 {
+		t.Errorf("expected only the cache file in %s, got %d files: %v", dir, len(infos), err)
+	}
+}
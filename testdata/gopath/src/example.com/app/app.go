@@ -0,0 +1,14 @@
+package app
+
+import (
+	"fmt"
+
+	"example.com/app/internal/util"
+	"github.com/foo/bar/baz"
+	"github.com/pkg/errors"
+)
+
+func Run() error {
+	fmt.Println(util.Upper(baz.Name()))
+	return errors.New("done")
+}
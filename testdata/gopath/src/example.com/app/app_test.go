@@ -0,0 +1,11 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/foo/assert"
+)
+
+func TestRun(t *testing.T) {
+	assert.Error(t, Run())
+}
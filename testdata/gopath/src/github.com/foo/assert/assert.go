@@ -0,0 +1,13 @@
+package assert
+
+import "fmt"
+
+type T interface {
+	Fatal(args ...interface{})
+}
+
+func Error(t T, err error) {
+	if err == nil {
+		t.Fatal(fmt.Sprint("expected an error"))
+	}
+}
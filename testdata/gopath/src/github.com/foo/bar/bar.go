@@ -0,0 +1,7 @@
+package bar
+
+import "strings"
+
+func Join(s ...string) string {
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,13 @@
+package baz
+
+import (
+	"errors"
+
+	"github.com/foo/bar"
+)
+
+var ErrEmpty = errors.New("empty")
+
+func Name() string {
+	return bar.Join("foo", "bar", "baz")
+}
@@ -0,0 +1,181 @@
+package gdl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// An UnusedPackage is a vendored package that is not a dependency.
+type UnusedPackage struct {
+	ImportPath string
+	Root       string // root import path of the repo of the package
+	Dir        string // directory relative to the listed directory
+	RepoUnused bool   // no package of the repo is a dependency
+}
+
+// findVendoredPackages returns the directories of all packages below the vendor directory by import path.
+// Directories ignored by the go tool, and those without any Go files, are skipped.
+func findVendoredPackages(vendorDir string) (map[string]string, error) {
+	dirs := make(map[string]string)
+	err := filepath.Walk(vendorDir, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && file == vendorDir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if file != vendorDir && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(file) != ".go" {
+			return nil
+		}
+		dir := filepath.Dir(file)
+		importPath, err := filepath.Rel(vendorDir, dir)
+		if err != nil {
+			return err
+		}
+		dirs[filepath.ToSlash(importPath)] = dir
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding vendored packages")
+	}
+	return dirs, nil
+}
+
+// Unused returns the vendored packages below the directory that are not dependencies of the packages.
+// Test dependencies only count as used with the Tests option,
+// and vendored packages needed by any of the platforms are used.
+// Also returns any problems that did not stop the search, i.e. an invalid repo cache.
+func Unused(ctx context.Context, opts Options) ([]*UnusedPackage, []string, error) {
+	opts.Standard, opts.SkipVendored = false, false
+	listing, err := Load(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings := append([]string{}, listing.Warnings...)
+	wd := listing.Dir
+	dirs, err := findVendoredPackages(filepath.Join(wd, "vendor"))
+	if err != nil {
+		return nil, nil, err
+	}
+	used := make(map[string]bool, len(listing.Deps))
+	for _, pkg := range listing.Deps {
+		if pkg.Vendored {
+			used[pkg.Dir] = true
+		}
+	}
+
+	// Find the repo of every vendored package, preferring the vendor manifest to resolving the repo.
+	manifest, err := readVendorManifest(wd)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		paths      = make([]string, 0, len(dirs))
+		roots      = make(map[string]string, len(dirs))
+		unresolved []*Package
+	)
+	for importPath := range dirs {
+		paths = append(paths, importPath)
+		if manifest != nil {
+			if p := manifest.Lookup(importPath); p != nil {
+				roots[importPath] = p.Root
+				continue
+			}
+		}
+		unresolved = append(unresolved, &Package{ImportPath: importPath})
+	}
+	sort.Strings(paths)
+	if len(unresolved) > 0 {
+		cache, cacheWarnings, err := openRepoCache(opts.CachePath, opts.CacheTTL, opts.Offline)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, cacheWarnings...)
+		repos, err := findRepos(ctx, unresolved, cache, opts.resolver(), opts.Concurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, pkg := range unresolved {
+			roots[pkg.ImportPath] = repos[i].Root
+		}
+	}
+
+	// A repo is used if any package below its root is used,
+	// even one of a different repo nested in its directory.
+	usedRepos := make(map[string]bool)
+	for importPath, dir := range dirs {
+		if !used[dir] {
+			continue
+		}
+		for p := importPath; p != "."; p = path.Dir(p) {
+			usedRepos[p] = true
+		}
+	}
+	var unused []*UnusedPackage
+	for _, importPath := range paths {
+		if used[dirs[importPath]] {
+			continue
+		}
+		rel, err := filepath.Rel(wd, dirs[importPath])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "finding directory of %s", importPath)
+		}
+		unused = append(unused, &UnusedPackage{
+			ImportPath: importPath,
+			Root:       roots[importPath],
+			Dir:        filepath.ToSlash(rel),
+			RepoUnused: !usedRepos[roots[importPath]],
+		})
+	}
+	return unused, warnings, nil
+}
+
+// PruneList returns the paths to remove to prune the unused packages, relative to the listed directory dir.
+// The directory of each unused repo is removed as a whole, otherwise only the files of
+// the unused packages are removed, keeping any sub packages and license files.
+func PruneList(dir string, unused []*UnusedPackage) ([]string, error) {
+	var paths []string
+	pruned := make(map[string]bool)
+	isPruned := func(importPath string) bool {
+		for p := importPath; p != "."; p = path.Dir(p) {
+			if pruned[p] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, u := range unused {
+		if isPruned(u.ImportPath) {
+			continue
+		}
+		if u.RepoUnused {
+			pruned[u.Root] = true
+			paths = append(paths, path.Join("vendor", u.Root))
+			continue
+		}
+		infos, err := ioutil.ReadDir(filepath.Join(dir, filepath.FromSlash(u.Dir)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading directory %s", u.Dir)
+		}
+		for _, info := range infos {
+			if info.Mode().IsRegular() && !isLicenseFile(info.Name()) {
+				paths = append(paths, path.Join(u.Dir, info.Name()))
+			}
+		}
+	}
+	return paths, nil
+}
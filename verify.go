@@ -0,0 +1,105 @@
+package gdl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// A VendorProblem is a vendored package that does not match the lock.
+type VendorProblem struct {
+	Status     string // one of tampered, patched, missing or extra
+	ImportPath string
+	Dir        string // directory relative to the listed directory
+}
+
+// hashPackageDir returns the hash of the files in the package directory.
+// The hash uses the same scheme as the h1 hashes of go.sum, but only includes the files
+// directly in the directory as sub directories are separate packages.
+func hashPackageDir(dir string) (string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading directory %s", dir)
+	}
+	summary := sha256.New()
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return "", errors.Wrap(err, "hashing package")
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", errors.Wrap(err, "hashing package")
+		}
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), info.Name())
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// hasLocalChanges reports whether the path, relative to dir, has uncommitted changes in git.
+func hasLocalChanges(dir, path string) bool {
+	out, err := gitOutput(dir, "status", "--porcelain", "--", path)
+	return err == nil && out != ""
+}
+
+// verifyVendor compares the vendored dependencies to the locked packages, relative to wd.
+func verifyVendor(wd string, locked []*LockedPackage, deps Packages) ([]*VendorProblem, error) {
+	var problems []*VendorProblem
+	inLock := make(map[string]bool, len(locked))
+	for _, p := range locked {
+		inLock[p.ImportPath] = true
+		dir := filepath.Join(wd, filepath.FromSlash(p.Dir))
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			problems = append(problems, &VendorProblem{Status: "missing", ImportPath: p.ImportPath, Dir: p.Dir})
+			continue
+		}
+		hash, err := hashPackageDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if hash == p.Hash {
+			continue
+		}
+		status := "tampered"
+		if hasLocalChanges(wd, p.Dir) {
+			status = "patched"
+		}
+		problems = append(problems, &VendorProblem{Status: status, ImportPath: p.ImportPath, Dir: p.Dir})
+	}
+	for _, d := range deps {
+		if !d.Vendored || d.Dir == "" || inLock[d.ImportPath] {
+			continue
+		}
+		rel, err := filepath.Rel(wd, d.Dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding directory of %s", d.ImportPath)
+		}
+		problems = append(problems, &VendorProblem{Status: "extra", ImportPath: d.ImportPath, Dir: filepath.ToSlash(rel)})
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].ImportPath < problems[j].ImportPath })
+	return problems, nil
+}
+
+// Verify compares the vendored packages the packages depend on to the hashes recorded in the lock.
+// Returns every vendored package that does not match the lock.
+// The repos of the dependencies are not resolved, so the network is never used.
+func Verify(ctx context.Context, opts Options, lock *Lock) ([]*VendorProblem, error) {
+	listing, err := Load(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return verifyVendor(listing.Dir, lock.Vendor, listing.Deps)
+}
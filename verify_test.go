@@ -0,0 +1,41 @@
+package gdl_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathanielc/gdl"
+	"github.com/nathanielc/gdl/internal/gdltest"
+	"golang.org/x/tools/go/vcs"
+)
+
+// resolverFunc resolves repos with a function.
+type resolverFunc func(importPath string) (*vcs.RepoRoot, error)
+
+func (f resolverFunc) RepoRoot(importPath string) (*vcs.RepoRoot, error) {
+	return f(importPath)
+}
+
+func TestVerify(t *testing.T) {
+	gopath, err := filepath.Abs(filepath.Join("testdata", "gopath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := gdl.Options{
+		ImportPaths: []string{"./..."},
+		Dir:         filepath.Join(gopath, "src", "example.com", "app"),
+		Runner:      gdltest.Go{Testdata: "testdata", GOPATH: gopath},
+		Resolver: resolverFunc(func(importPath string) (*vcs.RepoRoot, error) {
+			t.Errorf("unexpected repo lookup of %s", importPath)
+			return gdltest.Repos.RepoRoot(importPath)
+		}),
+	}
+	problems, err := gdl.Verify(context.Background(), opts, &gdl.Lock{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Status != "extra" || problems[0].ImportPath != "github.com/pkg/errors" {
+		t.Errorf("expected github.com/pkg/errors as an extra package, got %+v", problems)
+	}
+}
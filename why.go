@@ -0,0 +1,110 @@
+package gdl
+
+// An ImportChain is a list of imports from a listed package to a dependency.
+type ImportChain struct {
+	Start   string
+	Imports []*GraphEdge
+}
+
+// ImportChains returns at most max import chains from any listed node to the target,
+// or every import chain if max is zero or less.
+// The chains are ordered by length, the number of chains can grow exponentially with the size of the graph.
+func (g *Graph) ImportChains(target string, max int) []ImportChain {
+	out := make(map[string][]*GraphEdge)
+	in := make(map[string][]*GraphEdge)
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e)
+		in[e.To] = append(in[e.To], e)
+	}
+
+	// Find the distance of every node to the target by walking the edges backwards.
+	dist := map[string]int{target: 0}
+	queue := []string{target}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, e := range in[id] {
+			if _, ok := dist[e.From]; !ok {
+				dist[e.From] = dist[id] + 1
+				queue = append(queue, e.From)
+			}
+		}
+	}
+
+	var starts []string
+	for _, n := range g.Nodes {
+		if _, ok := dist[n.ID]; ok && n.Listed {
+			starts = append(starts, n.ID)
+		}
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	if max == 1 {
+		start := starts[0]
+		for _, id := range starts[1:] {
+			if dist[id] < dist[start] {
+				start = id
+			}
+		}
+		// Follow any edge that gets closer to the target.
+		chain := ImportChain{Start: start}
+		for id := start; id != target; {
+			for _, e := range out[id] {
+				if d, ok := dist[e.To]; ok && d == dist[id]-1 {
+					chain.Imports = append(chain.Imports, e)
+					id = e.To
+					break
+				}
+			}
+		}
+		return []ImportChain{chain}
+	}
+
+	// Walk the paths that can reach the target by increasing length, skipping cycles, until there are max chains.
+	// Only the imports that can still reach the target within the length are followed.
+	var chains []ImportChain
+	var imports []*GraphEdge
+	visiting := make(map[string]bool)
+	longer := false // a path was cut short by the length
+	var walk func(start, id string, length int)
+	walk = func(start, id string, length int) {
+		if max > 0 && len(chains) == max {
+			return
+		}
+		if id == target {
+			if len(imports) == length {
+				chains = append(chains, ImportChain{
+					Start:   start,
+					Imports: append([]*GraphEdge(nil), imports...),
+				})
+			}
+			return
+		}
+		visiting[id] = true
+		for _, e := range out[id] {
+			d, ok := dist[e.To]
+			if !ok || visiting[e.To] {
+				continue
+			}
+			if len(imports)+1+d > length {
+				longer = true
+				continue
+			}
+			imports = append(imports, e)
+			walk(start, e.To, length)
+			imports = imports[:len(imports)-1]
+		}
+		visiting[id] = false
+	}
+	for length := 0; ; length++ {
+		longer = false
+		for _, start := range starts {
+			walk(start, start, length)
+		}
+		if !longer || (max > 0 && len(chains) == max) {
+			return chains
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package gdl_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nathanielc/gdl"
+)
+
+// ladder returns a graph with 2^n import chains from the listed package app to the package target,
+// and a single shortcut from app to target.
+func ladder(n int) *gdl.Graph {
+	g := &gdl.Graph{Nodes: []*gdl.GraphNode{{ID: "app", Listed: true}, {ID: "target"}}}
+	prev := "app"
+	for i := 0; i < n; i++ {
+		a, b, next := fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i), fmt.Sprintf("n%d", i)
+		g.Nodes = append(g.Nodes, &gdl.GraphNode{ID: a}, &gdl.GraphNode{ID: b}, &gdl.GraphNode{ID: next})
+		g.Edges = append(g.Edges,
+			&gdl.GraphEdge{From: prev, To: a}, &gdl.GraphEdge{From: prev, To: b},
+			&gdl.GraphEdge{From: a, To: next}, &gdl.GraphEdge{From: b, To: next})
+		prev = next
+	}
+	g.Edges = append(g.Edges, &gdl.GraphEdge{From: prev, To: "target"}, &gdl.GraphEdge{From: "app", To: "target"})
+	return g
+}
+
+func TestImportChains(t *testing.T) {
+	g := ladder(30)
+	shortest := g.ImportChains("target", 1)
+	if len(shortest) != 1 || len(shortest[0].Imports) != 1 {
+		t.Errorf("expected the direct import as the shortest chain, got %+v", shortest)
+	}
+	if chains := g.ImportChains("target", 100); len(chains) != 100 {
+		t.Errorf("expected 100 chains, got %d", len(chains))
+	}
+	if chains := ladder(4).ImportChains("target", 0); len(chains) != 1<<4+1 {
+		t.Errorf("expected %d chains, got %d", 1<<4+1, len(chains))
+	}
+	// The shortest chain is kept when the longer chains come first by name
+	g = &gdl.Graph{Nodes: []*gdl.GraphNode{{ID: "app", Listed: true}, {ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "y"}, {ID: "z"}, {ID: "target"}}}
+	for _, e := range [][2]string{{"app", "a"}, {"a", "b"}, {"b", "c"}, {"c", "target"}, {"a", "y"}, {"y", "c"}, {"app", "z"}, {"z", "target"}} {
+		g.Edges = append(g.Edges, &gdl.GraphEdge{From: e[0], To: e[1]})
+	}
+	chains := g.ImportChains("target", 2)
+	if len(chains) != 2 || len(chains[0].Imports) != 2 || chains[0].Imports[0].To != "z" || len(chains[1].Imports) != 4 {
+		t.Errorf("expected the chain through z and then a chain of 4 imports, got %+v", chains)
+	}
+	if chains := g.ImportChains("missing", 0); chains != nil {
+		t.Errorf("expected no chains, got %+v", chains)
+	}
+}